@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// cacheFetchTimeout bounds a lookup shared by several callers, which no longer depends on their contexts
+const cacheFetchTimeout = 5 * time.Minute
+
+// cache memoizes lookups for the lifetime of a provider instance, i.e. a single Terraform run.
+// Concurrent lookups of the same key are deduplicated into a single API call.
+type cache struct {
+	// stopContext cancels shared lookups when Terraform stops the provider
+	stopContext context.Context
+
+	mu     sync.Mutex
+	group  singleflight.Group
+	values map[string]interface{}
+
+	// generations are bumped on every invalidation of their key, so that lookups which were in flight
+	// while their key got invalidated do not store stale values
+	generations map[string]uint64
+}
+
+func newCache(stopContext context.Context) *cache {
+	return &cache{
+		stopContext: stopContext,
+		values:      map[string]interface{}{},
+		generations: map[string]uint64{},
+	}
+}
+
+// load returns the cached value for key, calling fetch to populate it on a miss. Errors are not cached.
+// The lookup is shared with concurrent callers, so fetch does not run on the context of the caller which
+// started it, whose cancellation would fail the others, but on one bounded by cacheFetchTimeout. Each caller
+// still stops waiting when its own context is done.
+func (c *cache) load(ctx context.Context, key string, fetch func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	if v, ok := c.values[key]; ok {
+		c.mu.Unlock()
+		return v, nil
+	}
+	// Registering the key lets invalidateFunc match it while the lookup is in flight
+	generation := c.generations[key]
+	c.generations[key] = generation
+	c.mu.Unlock()
+
+	result := c.group.DoChan(key, func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(detachedContext{Context: c.stopContext, values: ctx}, cacheFetchTimeout)
+		defer cancel()
+
+		v, err := fetch(fetchCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		if c.generations[key] == generation {
+			c.values[key] = v
+		}
+		c.mu.Unlock()
+
+		return v, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-result:
+		return r.Val, r.Err
+	}
+}
+
+// invalidate removes the given keys from the cache
+func (c *cache) invalidate(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		c.forget(key)
+	}
+}
+
+// invalidateFunc removes every entry for which match returns true. Lookups in flight are matched by
+// their key with a nil value.
+func (c *cache) invalidateFunc(match func(key string, value interface{}) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.generations {
+		if match(key, c.values[key]) {
+			c.forget(key)
+		}
+	}
+}
+
+// forget drops the value of a key and stops in-flight lookups of it from storing theirs, or from being
+// joined by new callers. It must be called with the lock held.
+func (c *cache) forget(key string) {
+	c.generations[key]++
+	delete(c.values, key)
+	c.group.Forget(key)
+}
+
+// detachedContext carries the values of a caller's context, such as its tracing span, without its
+// cancellation, which comes from the embedded context instead
+type detachedContext struct {
+	context.Context
+	values context.Context
+}
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.values.Value(key)
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheDeduplicatesConcurrentLookups(t *testing.T) {
+	c := newCache(context.Background())
+
+	var calls int32
+	fetch := func(context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return "value", nil
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			v, err := c.load(context.Background(), "key", fetch)
+			assert.NoError(t, err)
+			assert.Equal(t, "value", v)
+		}()
+	}
+	wg.Wait()
+
+	_, _ = c.load(context.Background(), "key", fetch)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	c.invalidate("key")
+	_, _ = c.load(context.Background(), "key", fetch)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCacheDoesNotStoreValuesInvalidatedInFlight(t *testing.T) {
+	c := newCache(context.Background())
+
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		_, _ = c.load(context.Background(), "key", func(context.Context) (interface{}, error) {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			return "stale", nil
+		})
+	}()
+
+	<-started
+	c.invalidate("key")
+	<-done
+
+	v, err := c.load(context.Background(), "key", func(context.Context) (interface{}, error) {
+		return "fresh", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "fresh", v)
+}
+
+func TestCacheSharedLookupOutlivesCancelledCaller(t *testing.T) {
+	c := newCache(context.Background())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (interface{}, error) {
+		close(started)
+		select {
+		case <-release:
+			return "value", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error)
+	go func() {
+		_, err := c.load(first, "key", fetch)
+		firstDone <- err
+	}()
+	<-started
+
+	secondDone := make(chan interface{})
+	go func() {
+		v, err := c.load(context.Background(), "key", fetch)
+		assert.NoError(t, err)
+		secondDone <- v
+	}()
+
+	// The caller which started the lookup gives up, the one which joined it gets the value
+	cancel()
+	assert.ErrorIs(t, <-firstDone, context.Canceled)
+
+	close(release)
+	assert.Equal(t, "value", <-secondDone)
+}
+
+func TestCacheInvalidationIsPerKey(t *testing.T) {
+	c := newCache(context.Background())
+
+	var calls int32
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		_, _ = c.load(context.Background(), "key", func(context.Context) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			return "value", nil
+		})
+	}()
+
+	<-started
+	c.invalidate("other")
+	<-done
+
+	// The lookup in flight was not affected by the invalidation of another key, so it was stored
+	v, err := c.load(context.Background(), "key", func(context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "fresh", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "value", v)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -1,34 +1,53 @@
 package client
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"net/http"
 	"net/url"
 	"strings"
 
 	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
-
-	"github.com/CircleCI-Public/circleci-cli/api"
-	"github.com/CircleCI-Public/circleci-cli/settings"
 )
 
 // Client provides access to the CircleCI REST API
-// It uses upstream client functionality where possible and defines its own methods as needed
 type Client struct {
-	contexts     *api.ContextRestClient
-	rest         *rest.Client
-	vcs          string
-	organization string
+	stopContext   context.Context
+	rest          *rest.Client
+	restV1        *rest.Client
+	serverVersion *ServerVersion
+	limiter       *limiter
+	cache         *cache
+	vcs           string
+	organization  string
 }
 
 // Config configures a Client
 type Config struct {
 	URL   string
-	Token string
+	Token rest.TokenSource
+
+	// APIv1URL is the URL of API v1.1. It defaults to /api/v1.1/ on the host of URL.
+	APIv1URL string
+	// ServerVersion is the version of a self-hosted CircleCI server, e.g. 3.4. It is empty for CircleCI cloud.
+	ServerVersion string
 
 	VCS          string
 	Organization string
+
+	Transport rest.TransportConfig
+	Retry     rest.RetryPolicy
+
+	// MaxConcurrentRequests bounds the number of requests in flight. Zero means unlimited.
+	MaxConcurrentRequests int
+	// RequestsPerSecond bounds the rate at which requests are sent. Zero means unlimited.
+	RequestsPerSecond float64
+
+	// ReadOnly makes the client refuse any operation which would modify CircleCI
+	ReadOnly bool
+
+	// StopContext is cancelled when Terraform stops the provider, e.g. on interrupt.
+	// It defaults to context.Background().
+	StopContext context.Context
 }
 
 // New initializes a client object for the provider
@@ -40,34 +59,106 @@ func New(config Config) (*Client, error) {
 
 	rootURL := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
 
-	contexts, err := api.NewContextRestClient(settings.Config{
-		Host:         rootURL,
-		RestEndpoint: u.Path,
-		Token:        config.Token,
-		HTTPClient:   http.DefaultClient,
-	})
+	v1URL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/api/v1.1/"}
+	if config.APIv1URL != "" {
+		if v1URL, err = url.Parse(config.APIv1URL); err != nil {
+			return nil, err
+		}
+	}
+
+	vcs := VCSGitHub
+	if config.VCS != "" {
+		if vcs, err = NormalizeVCS(config.VCS); err != nil {
+			return nil, err
+		}
+	}
+
+	serverVersion, err := ParseServerVersion(config.ServerVersion)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{
-		rest:     rest.New(rootURL, u.Path, config.Token),
-		contexts: contexts,
+	transport, err := rest.NewTransport(config.Transport)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retries go through the limiter as well, since they count against the API rate limits
+	limiter := newLimiter(config.MaxConcurrentRequests, config.RequestsPerSecond)
+	httpClient := rest.NewHTTPClient(limiter.Transport(transport), config.Retry)
+
+	tokens := config.Token
+	if tokens == nil {
+		tokens = rest.StaticToken("")
+	}
+
+	restClient, err := rest.New(rootURL, u.Path, tokens, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	restClient.SetReadOnly(config.ReadOnly)
+
+	restV1Client, err := rest.New(fmt.Sprintf("%s://%s", v1URL.Scheme, v1URL.Host), v1URL.Path, tokens, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	restV1Client.SetReadOnly(config.ReadOnly)
 
-		vcs:          config.VCS,
+	stopContext := config.StopContext
+	if stopContext == nil {
+		stopContext = context.Background()
+	}
+
+	return &Client{
+		stopContext:   stopContext,
+		rest:          restClient,
+		restV1:        restV1Client,
+		serverVersion: serverVersion,
+		limiter:       limiter,
+		cache:         newCache(stopContext),
+
+		vcs:          vcs,
 		organization: config.Organization,
 	}, nil
 }
 
+// StopContext returns a context which is cancelled when Terraform stops the provider.
+// Operations should derive their contexts from it, so that in-flight requests get cancelled.
+func (c *Client) StopContext() context.Context {
+	return c.stopContext
+}
+
 // Organization returns the organization for a request. The organization configured
 // in the provider is returned.
 func (c *Client) Organization() string {
 	return c.organization
 }
 
-// Slug returns a project slug, including the VCS, organization, and project names
-func (c *Client) Slug(project string) (string, error) {
-	return fmt.Sprintf("%s/%s/%s", c.vcs, c.organization, project), nil
+// VCS returns the VCS type of the organization, in its slug form, e.g. gh
+func (c *Client) VCS() string {
+	return c.vcs
+}
+
+// WithOrganization returns a client acting on another organization. It shares the connections, limits
+// and cache of the original client. Empty values keep the organization or VCS type of the original client.
+func (c *Client) WithOrganization(vcs, organization string) (*Client, error) {
+	if vcs == "" && organization == "" {
+		return c, nil
+	}
+
+	other := *c
+	if vcs != "" {
+		normalized, err := NormalizeVCS(vcs)
+		if err != nil {
+			return nil, err
+		}
+		other.vcs = normalized
+	}
+	if organization != "" {
+		other.organization = organization
+	}
+
+	return &other, nil
 }
 
 func (c *Client) DecomposeElementId(id string, identifiers []string) (map[string]string, error) {
@@ -106,12 +197,3 @@ func (c *Client) DecomposeElementId(id string, identifiers []string) (map[string
 func (c *Client) ComposeElementId(identifiers []string) (string, error) {
 	return strings.Join(identifiers, "/"), nil
 }
-
-func isNotFound(err error) bool {
-	var httpError *rest.HTTPError
-	if errors.As(err, &httpError) && httpError.Code == 404 {
-		return true
-	}
-
-	return false
-}
@@ -1,83 +1,262 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
+	"time"
+
+	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
 
-	"github.com/CircleCI-Public/circleci-cli/api"
 	"github.com/google/uuid"
 )
 
-var ErrContextNotFound = errors.New("context not found")
+// Context is a set of environment variables shared between projects
+type Context struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
 
 // GetContext gets an existing context by its ID (UUID)
-func (c *Client) GetContext(id string) (*api.Context, error) {
-	req, err := c.rest.NewRequest("GET", &url.URL{Path: fmt.Sprintf("context/%s", id)}, nil)
+func (c *Client) GetContext(ctx context.Context, id string) (_ *Context, err error) {
+	ctx, span := startSpan(ctx, "GetContext", attributeContextID.String(id))
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureContexts)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := api.NewRequest(ctx, "GET", &url.URL{Path: fmt.Sprintf("context/%s", id)}, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	ctx := &api.Context{}
+	circleContext := &Context{}
+
+	if _, err := api.DoRequest(req, circleContext); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w (%v)", ErrContextNotFound, err)
+		}
+
+		return nil, err
+	}
+
+	return circleContext, nil
+}
+
+// Types of context owners
+const (
+	ContextOwnerOrganization = "organization"
+	ContextOwnerAccount      = "account"
+)
+
+// ContextOwner is the organization or account owning a context. It is referenced either by its ID or by
+// its slug, e.g. gh/org; GitLab and GitHub App organizations can only be referenced by their ID.
+type ContextOwner struct {
+	ID   string `json:"id,omitempty"`
+	Slug string `json:"slug,omitempty"`
+	Type string `json:"type"`
+}
+
+// query returns the query parameters selecting the contexts of the owner
+func (o *ContextOwner) query() url.Values {
+	if o.ID != "" {
+		return url.Values{"owner-id": {o.ID}}
+	}
+
+	return url.Values{"owner-slug": {o.Slug}, "owner-type": {o.Type}}
+}
 
-	status, err := c.rest.DoRequest(req, ctx)
+func (o *ContextOwner) key() string {
+	if o.ID != "" {
+		return "id/" + o.ID
+	}
+
+	return fmt.Sprintf("slug/%s/%s", o.Type, o.Slug)
+}
+
+// DefaultContextOwner returns the owner of the contexts of the configured organization. GitLab and GitHub App
+// organizations are referenced by their ID, which is resolved from their name if needed.
+func (c *Client) DefaultContextOwner(ctx context.Context) (*ContextOwner, error) {
+	if c.vcs != VCSCircleCI {
+		return &ContextOwner{Slug: c.OrganizationSlug(), Type: ContextOwnerOrganization}, nil
+	}
+
+	id, err := c.OrganizationID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContextOwner{ID: id, Type: ContextOwnerOrganization}, nil
+}
+
+// GetContextByName gets an existing context of the configured organization by its name.
+// Lookups are cached for the lifetime of the client.
+func (c *Client) GetContextByName(ctx context.Context, name string) (_ *Context, err error) {
+	owner, err := c.DefaultContextOwner(ctx)
 	if err != nil {
-		if status == 404 {
-			return nil, ErrContextNotFound
+		return nil, err
+	}
+
+	return c.GetOwnedContextByName(ctx, owner, name)
+}
+
+// GetOwnedContextByName gets an existing context of the given owner by its name.
+// Lookups are cached for the lifetime of the client.
+func (c *Client) GetOwnedContextByName(ctx context.Context, owner *ContextOwner, name string) (_ *Context, err error) {
+	ctx, span := startSpan(ctx, "GetContextByName", attributeContextName.String(name))
+	defer func() { endSpan(span, err) }()
+
+	v, err := c.cache.load(ctx, contextNameKey(owner, name), func(ctx context.Context) (interface{}, error) {
+		return c.findContext(ctx, owner, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	circleContext := *v.(*Context)
+	return &circleContext, nil
+}
+
+// findContext pages through the contexts of an owner until it finds the one with the given name
+func (c *Client) findContext(ctx context.Context, owner *ContextOwner, name string) (*Context, error) {
+	api, err := c.api(featureContexts)
+	if err != nil {
+		return nil, err
+	}
+
+	pager := rest.NewPager[Context](ctx, api, &url.URL{
+		Path:     "context",
+		RawQuery: owner.query().Encode(),
+	})
+
+	for pager.Next() {
+		if circleContext := pager.Item(); circleContext.Name == name {
+			return &circleContext, nil
 		}
+	}
 
+	if err := pager.Err(); err != nil {
 		return nil, err
 	}
 
-	return ctx, nil
+	return nil, fmt.Errorf("%w: %s", ErrContextNotFound, name)
 }
 
-// GetContextByName gets an existing context by its name
-func (c *Client) GetContextByName(name string) (*api.Context, error) {
-	return c.contexts.ContextByName(c.vcs, c.organization, name)
+// ListContexts lists all contexts of the organization
+func (c *Client) ListContexts(ctx context.Context) (_ []Context, err error) {
+	ctx, span := startSpan(ctx, "ListContexts")
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureContexts)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := c.DefaultContextOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rest.ListAll[Context](ctx, api, &url.URL{
+		Path:     "context",
+		RawQuery: owner.query().Encode(),
+	})
 }
 
 // GetContextByIDOrName gets a context by ID if a UUID is specified, and by name otherwise
-func (c *Client) GetContextByIDOrName(id string) (*api.Context, error) {
+func (c *Client) GetContextByIDOrName(ctx context.Context, id string) (*Context, error) {
 	if _, uuidErr := uuid.Parse(id); uuidErr == nil {
-		return c.GetContext(id)
+		return c.GetContext(ctx, id)
 	} else {
-		return c.contexts.ContextByName(c.vcs, c.organization, id)
+		return c.GetContextByName(ctx, id)
 	}
 }
 
 type createContextRequest struct {
 	Name  string        `json:"name"`
-	Owner *contextOwner `json:"owner"`
+	Owner *ContextOwner `json:"owner"`
 }
 
-type contextOwner struct {
-	Slug string `json:"slug"`
-	Type string `json:"type"`
+// CreateContext creates a new context in the configured organization and returns the created context object
+func (c *Client) CreateContext(ctx context.Context, name string) (_ *Context, err error) {
+	owner, err := c.DefaultContextOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.CreateOwnedContext(ctx, owner, name)
 }
 
-// CreateContext creates a new context and returns the created context object
-func (c *Client) CreateContext(name string) (*api.Context, error) {
-	req, err := c.rest.NewRequest("POST", &url.URL{Path: "context"}, &createContextRequest{
-		Name: name,
-		Owner: &contextOwner{
-			Slug: fmt.Sprintf("%s/%s", c.vcs, c.organization),
-			Type: "organization",
-		},
+// CreateOwnedContext creates a new context for the given owner and returns the created context object
+func (c *Client) CreateOwnedContext(ctx context.Context, owner *ContextOwner, name string) (_ *Context, err error) {
+	ctx, span := startSpan(ctx, "CreateContext", attributeContextName.String(name))
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureContexts)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := api.NewRequest(ctx, "POST", &url.URL{Path: "context"}, &createContextRequest{
+		Name:  name,
+		Owner: owner,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	ctx := &api.Context{}
-	_, err = c.rest.DoRequest(req, ctx)
+	circleContext := &Context{}
+	_, err = api.DoRequest(req, circleContext)
 	if err != nil {
 		return nil, err
 	}
 
-	return ctx, nil
+	c.cache.invalidate(contextNameKey(owner, name))
+	span.SetAttributes(attributeContextID.String(circleContext.ID))
+
+	return circleContext, nil
+}
+
+// DeleteContext deletes a context by its ID
+func (c *Client) DeleteContext(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteContext", attributeContextID.String(id))
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureContexts)
+	if err != nil {
+		return err
+	}
+
+	req, err := api.NewRequest(ctx, "DELETE", &url.URL{Path: fmt.Sprintf("context/%s", id)}, nil)
+	if err != nil {
+		return err
+	}
+
+	defer c.invalidateContext(id)
+
+	_, err = api.DoRequest(req, nil)
+	return err
+}
+
+// invalidateContext drops every cached lookup involving the given context
+func (c *Client) invalidateContext(id string) {
+	c.cache.invalidateFunc(func(key string, value interface{}) bool {
+		if circleContext, ok := value.(*Context); ok {
+			return circleContext.ID == id
+		}
+
+		return key == contextVariablesKey(id)
+	})
+}
+
+func contextNameKey(owner *ContextOwner, name string) string {
+	return fmt.Sprintf("context-name/%s/%s", owner.key(), name)
 }
 
-func (c *Client) DeleteContext(id string) error {
-	return c.contexts.DeleteContext(id)
+func contextVariablesKey(id string) string {
+	return fmt.Sprintf("context-variables/%s", id)
 }
@@ -1,62 +1,205 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"time"
 
-	"github.com/CircleCI-Public/circleci-cli/api"
+	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
 )
 
+// EnvironmentVariable is an environment variable defined in a context. Its value is never returned by the API.
+type EnvironmentVariable struct {
+	Variable  string    `json:"variable"`
+	ContextID string    `json:"context_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LastWrittenAt returns when the value of the variable was last written. Older servers do not report
+// updates, in which case the creation time is returned.
+func (v *EnvironmentVariable) LastWrittenAt() time.Time {
+	if v.UpdatedAt.IsZero() {
+		return v.CreatedAt
+	}
+
+	return v.UpdatedAt
+}
+
+type contextEnvironmentVariable struct {
+	Value string `json:"value"`
+}
+
 // CreateOrUpdateContextEnvironmentVariable creates a new context environment variable
-func (c *Client) CreateOrUpdateContextEnvironmentVariable(context_name, variable, value string) error {
+func (c *Client) CreateOrUpdateContextEnvironmentVariable(ctx context.Context, context_name, variable, value string) (err error) {
+	ctx, span := startSpan(ctx, "CreateOrUpdateContextEnvironmentVariable", attributeContextName.String(context_name), attributeVariableName.String(variable))
+	defer func() { endSpan(span, err) }()
+
 	// Find context ID
-	ctx, err := c.GetContextByName(context_name)
+	circleContext, err := c.GetContextByName(ctx, context_name)
+	if err != nil {
+		return fmt.Errorf("could not find context by name: %w", err)
+	}
+
+	return c.CreateOrUpdateContextEnvironmentVariableByID(ctx, circleContext.ID, variable, value)
+}
+
+// CreateOrUpdateContextEnvironmentVariableByID creates a new environment variable in the context with the given ID
+func (c *Client) CreateOrUpdateContextEnvironmentVariableByID(ctx context.Context, contextID, variable, value string) (err error) {
+	ctx, span := startSpan(ctx, "CreateOrUpdateContextEnvironmentVariableByID", attributeContextID.String(contextID), attributeVariableName.String(variable))
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureContexts)
+	if err != nil {
+		return err
+	}
+
+	// The endpoint uses PUT and can be used to update an existing variable with a matching context/name
+	req, err := api.NewRequest(ctx, "PUT", &url.URL{Path: fmt.Sprintf("context/%s/environment-variable/%s", contextID, variable)}, &contextEnvironmentVariable{
+		Value: value,
+	})
 	if err != nil {
-		return fmt.Errorf("could not find context by name: %v", err)
+		return err
 	}
 
-	// CreateEnvironmentVariable calls PUT and can be used to update an existing variable with a matching context/name
-	return c.contexts.CreateEnvironmentVariable(ctx.ID, variable, value)
+	defer c.cache.invalidate(contextVariablesKey(contextID))
+
+	_, err = api.DoRequest(req, nil)
+	return err
 }
 
-// ListContextEnvironmentVariables lists all environment variables for a given context
-func (c *Client) ListContextEnvironmentVariables(context_name string) (*[]api.EnvironmentVariable, error) {
+// ListContextEnvironmentVariables lists all environment variables for a given context.
+// Listings are cached for the lifetime of the client and invalidated when a variable of the context changes.
+func (c *Client) ListContextEnvironmentVariables(ctx context.Context, context_name string) (_ *[]EnvironmentVariable, err error) {
+	ctx, span := startSpan(ctx, "ListContextEnvironmentVariables", attributeContextName.String(context_name))
+	defer func() { endSpan(span, err) }()
+
 	// Find context ID
-	ctx, err := c.GetContextByName(context_name)
+	circleContext, err := c.GetContextByName(ctx, context_name)
+	if err != nil {
+		return nil, fmt.Errorf("could not find context by name: %w", err)
+	}
+
+	return c.ListContextEnvironmentVariablesByID(ctx, circleContext.ID)
+}
+
+// ListContextEnvironmentVariablesByID lists all environment variables for the context with the given ID
+func (c *Client) ListContextEnvironmentVariablesByID(ctx context.Context, contextID string) (_ *[]EnvironmentVariable, err error) {
+	ctx, span := startSpan(ctx, "ListContextEnvironmentVariablesByID", attributeContextID.String(contextID))
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureContexts)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := c.cache.load(ctx, contextVariablesKey(contextID), func(ctx context.Context) (interface{}, error) {
+		return rest.ListAll[EnvironmentVariable](ctx, api, &url.URL{Path: fmt.Sprintf("context/%s/environment-variable", contextID)})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy the listing, so that callers cannot modify the cached one
+	envs := append([]EnvironmentVariable(nil), v.([]EnvironmentVariable)...)
+	return &envs, nil
+}
+
+// FetchContextEnvironmentVariablesByID lists all environment variables for the context with the given ID
+// from the API, ignoring and replacing any cached listing
+func (c *Client) FetchContextEnvironmentVariablesByID(ctx context.Context, contextID string) (*[]EnvironmentVariable, error) {
+	c.cache.invalidate(contextVariablesKey(contextID))
+	return c.ListContextEnvironmentVariablesByID(ctx, contextID)
+}
+
+// GetContextEnvironmentVariable lists all environment variables for a given context and returns the specified one.
+// If either the context or the variable does not exist, it returns an error matching ErrNotFound.
+func (c *Client) GetContextEnvironmentVariable(ctx context.Context, context_name, variable string) (_ *EnvironmentVariable, err error) {
+	ctx, span := startSpan(ctx, "GetContextEnvironmentVariable", attributeContextName.String(context_name), attributeVariableName.String(variable))
+	defer func() { endSpan(span, err) }()
+
+	envs, err := c.ListContextEnvironmentVariables(ctx, context_name)
+	if err != nil {
+		return nil, err
+	}
+
+	return findContextEnvironmentVariable(*envs, variable)
+}
+
+// GetContextEnvironmentVariableByID returns an environment variable of the context with the given ID.
+// If either the context or the variable does not exist, it returns an error matching ErrNotFound.
+func (c *Client) GetContextEnvironmentVariableByID(ctx context.Context, contextID, variable string) (_ *EnvironmentVariable, err error) {
+	ctx, span := startSpan(ctx, "GetContextEnvironmentVariableByID", attributeContextID.String(contextID), attributeVariableName.String(variable))
+	defer func() { endSpan(span, err) }()
+
+	envs, err := c.ListContextEnvironmentVariablesByID(ctx, contextID)
 	if err != nil {
-		return nil, fmt.Errorf("could not find context by name: %v", err)
+		return nil, err
+	}
+
+	return findContextEnvironmentVariable(*envs, variable)
+}
+
+func findContextEnvironmentVariable(envs []EnvironmentVariable, variable string) (*EnvironmentVariable, error) {
+	for _, env := range envs {
+		if env.Variable == variable {
+			return &env, nil
+		}
 	}
 
-	return c.contexts.EnvironmentVariables(ctx.ID)
+	return nil, fmt.Errorf("environment variable %s %w", variable, ErrNotFound)
 }
 
 // HasContextEnvironmentVariable lists all environment variables for a given context and checks whether the specified variable is defined.
 // If either the context or the variable does not exist, it returns false.
-func (c *Client) HasContextEnvironmentVariable(context_name, variable string) (bool, error) {
-	envs, err := c.ListContextEnvironmentVariables(context_name)
-	if err != nil {
-		if isNotFound(err) {
+func (c *Client) HasContextEnvironmentVariable(ctx context.Context, context_name, variable string) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "HasContextEnvironmentVariable", attributeContextName.String(context_name), attributeVariableName.String(variable))
+	defer func() { endSpan(span, err) }()
+
+	if _, err := c.GetContextEnvironmentVariable(ctx, context_name, variable); err != nil {
+		if errors.Is(err, ErrNotFound) {
 			return false, nil
 		}
 
 		return false, err
 	}
 
-	for _, env := range *envs {
-		if env.Variable == variable {
-			return true, nil
-		}
+	return true, nil
+}
+
+// DeleteContextEnvironmentVariable deletes a context environment variable by context name and variable name
+func (c *Client) DeleteContextEnvironmentVariable(ctx context.Context, context_name, variable string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteContextEnvironmentVariable", attributeContextName.String(context_name), attributeVariableName.String(variable))
+	defer func() { endSpan(span, err) }()
+
+	// Find context ID
+	circleContext, err := c.GetContextByName(ctx, context_name)
+	if err != nil {
+		return fmt.Errorf("could not find context by name: %w", err)
 	}
 
-	return false, nil
+	return c.DeleteContextEnvironmentVariableByID(ctx, circleContext.ID, variable)
 }
 
-// DeleteContextEnvironmentVariable deletes a context environment variable by context ID and name
-func (c *Client) DeleteContextEnvironmentVariable(context_name, variable string) error {
-	// Find context ID
-	ctx, err := c.GetContextByName(context_name)
+// DeleteContextEnvironmentVariableByID deletes a context environment variable by context ID and name
+func (c *Client) DeleteContextEnvironmentVariableByID(ctx context.Context, contextID, variable string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteContextEnvironmentVariableByID", attributeContextID.String(contextID), attributeVariableName.String(variable))
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureContexts)
 	if err != nil {
-		return fmt.Errorf("could not find context by name: %v", err)
+		return err
 	}
 
-	return c.contexts.DeleteEnvironmentVariable(ctx.ID, variable)
+	req, err := api.NewRequest(ctx, "DELETE", &url.URL{Path: fmt.Sprintf("context/%s/environment-variable/%s", contextID, variable)}, nil)
+	if err != nil {
+		return err
+	}
+
+	defer c.cache.invalidate(contextVariablesKey(contextID))
+
+	_, err = api.DoRequest(req, nil)
+	return err
 }
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetContextEnvironmentVariable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/context":
+			_, _ = w.Write([]byte(`{"items": [{"id": "c1", "name": "ctx"}], "next_page_token": null}`))
+		case "/api/v2/context/c1/environment-variable":
+			_, _ = w.Write([]byte(`{"items": [
+				{"variable": "UPDATED", "context_id": "c1", "created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-02-01T00:00:00.5Z"},
+				{"variable": "LEGACY", "context_id": "c1", "created_at": "2024-01-01T00:00:00Z"}
+			], "next_page_token": null}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(Config{URL: server.URL + "/api/v2/", Organization: "org"})
+	assert.NoError(t, err)
+
+	updated, err := c.GetContextEnvironmentVariable(context.Background(), "ctx", "UPDATED")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 500000000, time.UTC), updated.LastWrittenAt())
+
+	legacy, err := c.GetContextEnvironmentVariable(context.Background(), "ctx", "LEGACY")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), legacy.LastWrittenAt())
+
+	_, err = c.GetContextEnvironmentVariable(context.Background(), "ctx", "MISSING")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	has, err := c.HasContextEnvironmentVariable(context.Background(), "ctx", "MISSING")
+	assert.NoError(t, err)
+	assert.False(t, has)
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextOwners(t *testing.T) {
+	var queries []string
+	var created []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/me/collaborations":
+			_, _ = w.Write([]byte(`[{"id": "8c2b1a4e-0f5e-4c3a-9d7e-1a2b3c4d5e6f", "vcs-type": "circleci", "name": "my-gitlab-org", "slug": "circleci/8c2b1a4e-0f5e-4c3a-9d7e-1a2b3c4d5e6f"}]`))
+		case "/api/v2/context":
+			if r.Method == "POST" {
+				body, _ := io.ReadAll(r.Body)
+				created = append(created, string(body))
+				_, _ = w.Write([]byte(`{"id": "c1", "name": "ctx"}`))
+				return
+			}
+
+			queries = append(queries, r.URL.RawQuery)
+			_, _ = w.Write([]byte(`{"items": [{"id": "c1", "name": "ctx"}], "next_page_token": null}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	github, err := New(Config{URL: server.URL + "/api/v2/", VCS: "github", Organization: "org"})
+	assert.NoError(t, err)
+
+	gitlab, err := github.WithOrganization("gitlab", "my-gitlab-org")
+	assert.NoError(t, err)
+
+	for _, c := range []*Client{github, gitlab} {
+		circleContext, err := c.GetContextByName(context.Background(), "ctx")
+		assert.NoError(t, err)
+		assert.Equal(t, "c1", circleContext.ID)
+	}
+
+	_, err = github.GetOwnedContextByName(context.Background(), &ContextOwner{Slug: "gh/octocat", Type: ContextOwnerAccount}, "ctx")
+	assert.NoError(t, err)
+
+	_, err = gitlab.CreateContext(context.Background(), "ctx")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"owner-slug=gh%2Forg&owner-type=organization",
+		"owner-id=8c2b1a4e-0f5e-4c3a-9d7e-1a2b3c4d5e6f",
+		"owner-slug=gh%2Foctocat&owner-type=account",
+	}, queries)
+	assert.Equal(t, []string{
+		`{"name":"ctx","owner":{"id":"8c2b1a4e-0f5e-4c3a-9d7e-1a2b3c4d5e6f","type":"organization"}}` + "\n",
+	}, created)
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
+)
+
+// Classes of API errors, to be matched with errors.Is. Errors returned by the API also
+// carry the request method, path, status code and request ID, see rest.HTTPError.
+var (
+	ErrBadRequest   = rest.ErrBadRequest
+	ErrUnauthorized = rest.ErrUnauthorized
+	ErrForbidden    = rest.ErrForbidden
+	ErrNotFound     = rest.ErrNotFound
+	ErrConflict     = rest.ErrConflict
+	ErrRateLimited  = rest.ErrRateLimited
+	ErrServer       = rest.ErrServer
+)
+
+// ErrReadOnly is returned for operations which would modify CircleCI while the client is read-only
+var ErrReadOnly = rest.ErrReadOnly
+
+// ErrContextNotFound is returned when a context does not exist. It matches ErrNotFound as well.
+var ErrContextNotFound = fmt.Errorf("context %w", ErrNotFound)
+
+// ErrUnsupported is returned for operations which the configured CircleCI server version does not support
+var ErrUnsupported = errors.New("not supported on this server version")
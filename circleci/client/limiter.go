@@ -0,0 +1,88 @@
+package client
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// limiter throttles every outbound request of a provider instance, regardless of which client sends it.
+// It bounds both the number of requests in flight and the rate at which new requests are started.
+type limiter struct {
+	slots chan struct{}
+	rate  *rate.Limiter
+}
+
+func newLimiter(maxConcurrentRequests int, requestsPerSecond float64) *limiter {
+	l := &limiter{}
+
+	if maxConcurrentRequests > 0 {
+		l.slots = make(chan struct{}, maxConcurrentRequests)
+	}
+
+	if requestsPerSecond > 0 {
+		burst := int(math.Ceil(requestsPerSecond))
+		l.rate = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+	}
+
+	return l
+}
+
+// Transport wraps an http.RoundTripper so that requests sent through it wait for the limiter
+func (l *limiter) Transport(next http.RoundTripper) http.RoundTripper {
+	return &limitedTransport{limiter: l, next: next}
+}
+
+type limitedTransport struct {
+	limiter *limiter
+	next    http.RoundTripper
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	release := func() {}
+	if t.limiter.slots != nil {
+		select {
+		case t.limiter.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		once := &sync.Once{}
+		release = func() {
+			once.Do(func() { <-t.limiter.slots })
+		}
+	}
+
+	if t.limiter.rate != nil {
+		if err := t.limiter.rate.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	// The request is in flight until its response has been read
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+
+	return resp, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// roundTripperFunc adapts a function to an http.RoundTripper
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestLimiterCapsRequestsInFlight(t *testing.T) {
+	var inFlight, peak int32
+	transport := newLimiter(2, 0).Transport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	}))
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req, _ := http.NewRequest("GET", "http://circleci.test/", nil)
+			resp, err := transport.RoundTrip(req)
+			assert.NoError(t, err)
+			assert.NoError(t, resp.Body.Close())
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&peak))
+}
+
+func TestLimiterReleasesSlots(t *testing.T) {
+	failure := errors.New("failure")
+	fail := true
+	transport := newLimiter(1, 0).Transport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if fail {
+			return nil, failure
+		}
+
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	}))
+
+	roundTrip := func() (*http.Response, error) {
+		req, _ := http.NewRequest("GET", "http://circleci.test/", nil)
+		return transport.RoundTrip(req)
+	}
+
+	// A failed request releases its slot right away
+	_, err := roundTrip()
+	assert.ErrorIs(t, err, failure)
+
+	fail = false
+	resp, err := roundTrip()
+	assert.NoError(t, err)
+
+	// The slot is held until the body of the response is closed
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		second, err := roundTrip()
+		assert.NoError(t, err)
+		assert.NoError(t, second.Body.Close())
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("a request was sent before the previous response was closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.NoError(t, resp.Body.Close())
+	// Closing a body twice releases its slot only once
+	assert.NoError(t, resp.Body.Close())
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the slot was not released when the response was closed")
+	}
+}
+
+func TestLimiterThrottlesRequests(t *testing.T) {
+	var sent int32
+	transport := newLimiter(0, 20).Transport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&sent, 1)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	}))
+
+	start := time.Now()
+	for i := 0; i < 30; i++ {
+		req, _ := http.NewRequest("GET", "http://circleci.test/", nil)
+		resp, err := transport.RoundTrip(req)
+		assert.NoError(t, err)
+		assert.NoError(t, resp.Body.Close())
+	}
+
+	// The first 20 requests are a burst, the next 10 are sent at 20 per second
+	assert.Equal(t, int32(30), atomic.LoadInt32(&sent))
+	assert.GreaterOrEqual(t, time.Since(start), 450*time.Millisecond)
+}
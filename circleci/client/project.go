@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 )
@@ -22,21 +23,31 @@ type VCSInfo struct {
 }
 
 // GetProject gets an existing project by its project slug (vcs-slug/org-name/repo-name)
-func (c *Client) GetProject(project string) (*Project, error) {
-	slug, err := c.Slug(project)
+func (c *Client) GetProject(ctx context.Context, project string) (_ *Project, err error) {
+	ctx, span := startSpan(ctx, "GetProject")
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureProjects)
+	if err != nil {
+		return nil, err
+	}
+
+	projectSlug, err := c.ProjectSlug(ctx, project)
 	if err != nil {
 		return nil, err
 	}
+	span.SetAttributes(attributeProjectSlug.String(projectSlug.String()))
+	slug := c.slugPath(api, projectSlug)
 
-	req, err := c.rest.NewRequest("GET", &url.URL{Path: fmt.Sprintf("project/%s", slug)}, nil)
+	req, err := api.NewRequest(ctx, "GET", &url.URL{Path: fmt.Sprintf("project/%s", slug)}, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	p := &Project{}
 
-	if sts, err := c.rest.DoRequest(req, p); err != nil {
-		return nil, fmt.Errorf("could not find project: %v %v", sts, err)
+	if _, err := api.DoRequest(req, p); err != nil {
+		return nil, fmt.Errorf("could not find project: %w", err)
 	}
 
 	return p, nil
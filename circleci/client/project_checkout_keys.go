@@ -1,8 +1,13 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+
+	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
 )
 
 type CheckoutKey struct {
@@ -13,44 +18,99 @@ type CheckoutKey struct {
 	CreatedAt   string `json:"created_at"`
 }
 
+// checkoutKeyV1 is a checkout key as returned by API v1.1
+type checkoutKeyV1 struct {
+	CheckoutKey
+	Time string `json:"time"`
+}
+
+// checkoutKeyTypesV1 maps the key types of API v2 to those of API v1.1
+var checkoutKeyTypesV1 = map[string]string{
+	"user-key": "github-user-key",
+}
+
+// doCheckoutKeyRequest sends a request returning a checkout key, converting API v1.1 responses to their API v2 form
+func (c *Client) doCheckoutKeyRequest(api *rest.Client, req *http.Request) (*CheckoutKey, error) {
+	if api != c.restV1 {
+		key := &CheckoutKey{}
+		if _, err := api.DoRequest(req, key); err != nil {
+			return nil, err
+		}
+
+		return key, nil
+	}
+
+	key := &checkoutKeyV1{}
+	if _, err := api.DoRequest(req, key); err != nil {
+		return nil, err
+	}
+
+	key.CreatedAt = key.Time
+	for v2, v1 := range checkoutKeyTypesV1 {
+		if key.Type == v1 {
+			key.Type = v2
+		}
+	}
+
+	return &key.CheckoutKey, nil
+}
+
 // HasProjectCheckoutKey checks if an existing project contains checkout key by its fingerprint
-func (c *Client) HasProjectCheckoutKey(project, fingerprint string) (bool, error) {
-	slug, err := c.Slug(project)
+func (c *Client) HasProjectCheckoutKey(ctx context.Context, project, fingerprint string) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "HasProjectCheckoutKey")
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureCheckoutKeys)
 	if err != nil {
 		return false, err
 	}
 
-	req, err := c.rest.NewRequest("GET", &url.URL{Path: fmt.Sprintf("project/%s/checkout-key/%s", slug, fingerprint)}, nil)
+	projectSlug, err := c.ProjectSlug(ctx, project)
 	if err != nil {
 		return false, err
 	}
+	span.SetAttributes(attributeProjectSlug.String(projectSlug.String()))
+	slug := c.slugPath(api, projectSlug)
 
-	key := &CheckoutKey{}
-	if _, err := c.rest.DoRequest(req, key); err != nil {
-		return false, nil
+	req, err := api.NewRequest(ctx, "GET", &url.URL{Path: fmt.Sprintf("project/%s/checkout-key/%s", slug, fingerprint)}, nil)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := c.doCheckoutKeyRequest(api, req); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+
+		return false, err
 	}
 
 	return true, nil
 }
 
 // GetCheckoutKey gets an existing project's checkout key by its fingerprint
-func (c *Client) GetCheckoutKey(project, fingerprint string) (*CheckoutKey, error) {
-	slug, err := c.Slug(project)
+func (c *Client) GetCheckoutKey(ctx context.Context, project, fingerprint string) (_ *CheckoutKey, err error) {
+	ctx, span := startSpan(ctx, "GetCheckoutKey")
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureCheckoutKeys)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := c.rest.NewRequest("GET", &url.URL{Path: fmt.Sprintf("project/%s/checkout-key/%s", slug, fingerprint)}, nil)
+	projectSlug, err := c.ProjectSlug(ctx, project)
 	if err != nil {
 		return nil, err
 	}
+	span.SetAttributes(attributeProjectSlug.String(projectSlug.String()))
+	slug := c.slugPath(api, projectSlug)
 
-	key := &CheckoutKey{}
-	if _, err := c.rest.DoRequest(req, key); err != nil {
+	req, err := api.NewRequest(ctx, "GET", &url.URL{Path: fmt.Sprintf("project/%s/checkout-key/%s", slug, fingerprint)}, nil)
+	if err != nil {
 		return nil, err
 	}
 
-	return key, nil
+	return c.doCheckoutKeyRequest(api, req)
 }
 
 type createCheckoutKey struct {
@@ -58,40 +118,59 @@ type createCheckoutKey struct {
 }
 
 // CreateCheckoutKey creates a new checkout key and returns the created object
-func (c *Client) CreateCheckoutKey(project, keyType string) (*CheckoutKey, error) {
-	slug, err := c.Slug(project)
+func (c *Client) CreateCheckoutKey(ctx context.Context, project, keyType string) (_ *CheckoutKey, err error) {
+	ctx, span := startSpan(ctx, "CreateCheckoutKey")
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureCheckoutKeys)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := c.rest.NewRequest("POST", &url.URL{Path: fmt.Sprintf("project/%s/checkout-key", slug)}, &createCheckoutKey{
-		Type: keyType,
-	})
-
+	projectSlug, err := c.ProjectSlug(ctx, project)
 	if err != nil {
 		return nil, err
 	}
+	span.SetAttributes(attributeProjectSlug.String(projectSlug.String()))
+	slug := c.slugPath(api, projectSlug)
+
+	if typeV1, ok := checkoutKeyTypesV1[keyType]; ok && api == c.restV1 {
+		keyType = typeV1
+	}
 
-	key := &CheckoutKey{}
-	if _, err := c.rest.DoRequest(req, key); err != nil {
+	req, err := api.NewRequest(ctx, "POST", &url.URL{Path: fmt.Sprintf("project/%s/checkout-key", slug)}, &createCheckoutKey{
+		Type: keyType,
+	})
+
+	if err != nil {
 		return nil, err
 	}
 
-	return key, nil
+	return c.doCheckoutKeyRequest(api, req)
 }
 
 // DeleteCheckoutKey deletes an existing checkout key and returns the created object
-func (c *Client) DeleteCheckoutKey(project, fingerprint string) error {
-	slug, err := c.Slug(project)
+func (c *Client) DeleteCheckoutKey(ctx context.Context, project, fingerprint string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteCheckoutKey")
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureCheckoutKeys)
+	if err != nil {
+		return err
+	}
+
+	projectSlug, err := c.ProjectSlug(ctx, project)
 	if err != nil {
 		return err
 	}
+	span.SetAttributes(attributeProjectSlug.String(projectSlug.String()))
+	slug := c.slugPath(api, projectSlug)
 
-	req, err := c.rest.NewRequest("DELETE", &url.URL{Path: fmt.Sprintf("project/%s/checkout-key/%s", slug, fingerprint)}, nil)
+	req, err := api.NewRequest(ctx, "DELETE", &url.URL{Path: fmt.Sprintf("project/%s/checkout-key/%s", slug, fingerprint)}, nil)
 	if err != nil {
 		return err
 	}
 
-	_, err = c.rest.DoRequest(req, nil)
+	_, err = api.DoRequest(req, nil)
 	return err
 }
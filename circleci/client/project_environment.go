@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
@@ -8,31 +9,104 @@ import (
 	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
 )
 
-type projectEnvironmentVariable struct {
+// ProjectEnvironmentVariable is an environment variable defined in a project. The API only returns
+// a masked value, e.g. xxxxf00d.
+type ProjectEnvironmentVariable struct {
 	Name  string `json:"name"`
 	Value string `json:"value"`
 }
 
-// HasProjectEnvironmentVariable checks for the existence of a matching project environment variable by name
-func (c *Client) HasProjectEnvironmentVariable(project, name string) (bool, error) {
-	slug, err := c.Slug(project)
+// MaskProjectEnvironmentVariable masks a value the way the API does, keeping only its last four characters
+func MaskProjectEnvironmentVariable(value string) string {
+	runes := []rune(value)
+	if len(runes) > 4 {
+		runes = runes[len(runes)-4:]
+	}
+
+	return "xxxx" + string(runes)
+}
+
+// ListProjectEnvironmentVariables lists all environment variables of a project, with masked values
+func (c *Client) ListProjectEnvironmentVariables(ctx context.Context, project string) (_ []ProjectEnvironmentVariable, err error) {
+	ctx, span := startSpan(ctx, "ListProjectEnvironmentVariables")
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureProjectEnvironmentVariables)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+
+	projectSlug, err := c.ProjectSlug(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attributeProjectSlug.String(projectSlug.String()))
+	slug := c.slugPath(api, projectSlug)
+
+	u := &url.URL{
+		Path: fmt.Sprintf("project/%s/envvar", slug),
+	}
+
+	if api == c.restV1 {
+		// API v1.1 returns every variable at once
+		req, err := api.NewRequest(ctx, "GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var variables []ProjectEnvironmentVariable
+		if _, err := api.DoRequest(req, &variables); err != nil {
+			return nil, err
+		}
+
+		return variables, nil
+	}
+
+	return rest.ListAll[ProjectEnvironmentVariable](ctx, api, u)
+}
+
+// GetProjectEnvironmentVariable gets a project environment variable by name, with its masked value
+func (c *Client) GetProjectEnvironmentVariable(ctx context.Context, project, name string) (_ *ProjectEnvironmentVariable, err error) {
+	ctx, span := startSpan(ctx, "GetProjectEnvironmentVariable", attributeVariableName.String(name))
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureProjectEnvironmentVariables)
+	if err != nil {
+		return nil, err
+	}
+
+	projectSlug, err := c.ProjectSlug(ctx, project)
+	if err != nil {
+		return nil, err
 	}
+	span.SetAttributes(attributeProjectSlug.String(projectSlug.String()))
+	slug := c.slugPath(api, projectSlug)
 
 	u := &url.URL{
 		Path: fmt.Sprintf("project/%s/envvar/%s", slug, name),
 	}
 
-	req, err := c.rest.NewRequest("GET", u, nil)
+	req, err := api.NewRequest(ctx, "GET", u, nil)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+
+	variable := &ProjectEnvironmentVariable{}
+	if _, err := api.DoRequest(req, variable); err != nil {
+		return nil, err
 	}
 
-	_, err = c.rest.DoRequest(req, nil)
+	return variable, nil
+}
+
+// HasProjectEnvironmentVariable checks for the existence of a matching project environment variable by name
+func (c *Client) HasProjectEnvironmentVariable(ctx context.Context, project, name string) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "HasProjectEnvironmentVariable", attributeVariableName.String(name))
+	defer func() { endSpan(span, err) }()
+
+	_, err = c.GetProjectEnvironmentVariable(ctx, project, name)
 	if err != nil {
-		var httpError *rest.HTTPError
-		if errors.As(err, &httpError) && httpError.Code == 404 {
+		if errors.Is(err, ErrNotFound) {
 			return false, nil
 		}
 
@@ -42,18 +116,28 @@ func (c *Client) HasProjectEnvironmentVariable(project, name string) (bool, erro
 	return true, nil
 }
 
-// CreateProjectEnvironmentVariable creates a new project environment variable
-func (c *Client) CreateProjectEnvironmentVariable(project, name, value string) error {
-	slug, err := c.Slug(project)
+// CreateProjectEnvironmentVariable creates a new project environment variable, or overwrites the value of an existing one
+func (c *Client) CreateProjectEnvironmentVariable(ctx context.Context, project, name, value string) (err error) {
+	ctx, span := startSpan(ctx, "CreateProjectEnvironmentVariable", attributeVariableName.String(name))
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureProjectEnvironmentVariables)
+	if err != nil {
+		return err
+	}
+
+	projectSlug, err := c.ProjectSlug(ctx, project)
 	if err != nil {
 		return err
 	}
+	span.SetAttributes(attributeProjectSlug.String(projectSlug.String()))
+	slug := c.slugPath(api, projectSlug)
 
 	u := &url.URL{
 		Path: fmt.Sprintf("project/%s/envvar", slug),
 	}
 
-	req, err := c.rest.NewRequest("POST", u, &projectEnvironmentVariable{
+	req, err := api.NewRequest(ctx, "POST", u, &ProjectEnvironmentVariable{
 		Name:  name,
 		Value: value,
 	})
@@ -61,26 +145,36 @@ func (c *Client) CreateProjectEnvironmentVariable(project, name, value string) e
 		return err
 	}
 
-	_, err = c.rest.DoRequest(req, nil)
+	_, err = api.DoRequest(req, nil)
 	return err
 }
 
 // DeleteProjectEnvironmentVariable deletes an existing project environment variable
-func (c *Client) DeleteProjectEnvironmentVariable(project, name string) error {
-	slug, err := c.Slug(project)
+func (c *Client) DeleteProjectEnvironmentVariable(ctx context.Context, project, name string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteProjectEnvironmentVariable", attributeVariableName.String(name))
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureProjectEnvironmentVariables)
+	if err != nil {
+		return err
+	}
+
+	projectSlug, err := c.ProjectSlug(ctx, project)
 	if err != nil {
 		return err
 	}
+	span.SetAttributes(attributeProjectSlug.String(projectSlug.String()))
+	slug := c.slugPath(api, projectSlug)
 
 	u := &url.URL{
 		Path: fmt.Sprintf("project/%s/envvar/%s", slug, name),
 	}
 
-	req, err := c.rest.NewRequest("DELETE", u, nil)
+	req, err := api.NewRequest(ctx, "DELETE", u, nil)
 	if err != nil {
 		return err
 	}
 
-	_, err = c.rest.DoRequest(req, nil)
+	_, err = api.DoRequest(req, nil)
 	return err
 }
@@ -0,0 +1,16 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskProjectEnvironmentVariable(t *testing.T) {
+	assert.Equal(t, "xxxxf00d", MaskProjectEnvironmentVariable("deadbeeff00d"))
+	assert.Equal(t, "xxxxf00d", MaskProjectEnvironmentVariable("f00d"))
+	assert.Equal(t, "xxxxab", MaskProjectEnvironmentVariable("ab"))
+	assert.Equal(t, "xxxx", MaskProjectEnvironmentVariable(""))
+	assert.Equal(t, "xxxxdéjà", MaskProjectEnvironmentVariable("mot de passe déjà"))
+	assert.Equal(t, "xxxx🔑🔑🔑🔑", MaskProjectEnvironmentVariable("🔑🔑🔑🔑🔑"))
+}
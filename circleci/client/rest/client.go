@@ -2,38 +2,56 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 )
 
 type Client struct {
-	baseURL     *url.URL
-	circleToken string
-	client      *http.Client
+	baseURL  *url.URL
+	tokens   TokenSource
+	client   *http.Client
+	readOnly bool
 }
 
-func New(host, endpoint, circleToken string) *Client {
+// NewHTTPClient returns an HTTP client which sends requests through the given transport and retries
+// failed ones according to the given policy. Every attempt is logged according to TF_LOG and traced.
+// It is meant to be shared by every client of the provider.
+func NewHTTPClient(transport http.RoundTripper, policy RetryPolicy) *http.Client {
+	return &http.Client{
+		Transport: NewRetryTransport(policy, NewTracingTransport(NewLoggingTransport(transport))),
+	}
+}
+
+func New(host, endpoint string, tokens TokenSource, httpClient *http.Client) (*Client, error) {
 	// Ensure endpoint ends with a slash
 	if !strings.HasSuffix(endpoint, "/") {
 		endpoint += "/"
 	}
 
-	u, _ := url.Parse(host)
-	return &Client{
-		baseURL:     u.ResolveReference(&url.URL{Path: endpoint}),
-		circleToken: circleToken,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, err
 	}
+
+	return &Client{
+		baseURL: u.ResolveReference(&url.URL{Path: endpoint}),
+		tokens:  tokens,
+		client:  httpClient,
+	}, nil
 }
 
-func (c *Client) NewRequest(method string, u *url.URL, payload interface{}) (req *http.Request, err error) {
+// SetReadOnly makes the client refuse any request which is not a GET, so that it cannot modify CircleCI
+func (c *Client) SetReadOnly(readOnly bool) {
+	c.readOnly = readOnly
+}
+
+func (c *Client) NewRequest(ctx context.Context, method string, u *url.URL, payload interface{}) (req *http.Request, err error) {
 	var r io.Reader
 	if payload != nil {
 		buf := &bytes.Buffer{}
@@ -44,12 +62,17 @@ func (c *Client) NewRequest(method string, u *url.URL, payload interface{}) (req
 		}
 	}
 
-	req, err = http.NewRequest(method, c.baseURL.ResolveReference(u).String(), r)
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the API token: %w", err)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, method, c.baseURL.ResolveReference(u).String(), r)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Circle-Token", c.circleToken)
+	req.Header.Set("Circle-Token", token)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", "SectorLabs/terraform-provider-circleci")
 	if payload != nil {
@@ -60,21 +83,14 @@ func (c *Client) NewRequest(method string, u *url.URL, payload interface{}) (req
 }
 
 func (c *Client) DoRequest(req *http.Request, resp interface{}) (statusCode int, err error) {
-	httpResp, err := c.client.Do(req)
+	httpResp, err := c.do(req)
 	if err != nil {
 		return 0, err
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode >= 300 {
-		httpError := struct {
-			Message string `json:"message"`
-		}{}
-		err = json.NewDecoder(httpResp.Body).Decode(&httpError)
-		if err != nil {
-			return httpResp.StatusCode, err
-		}
-		return httpResp.StatusCode, &HTTPError{Code: httpResp.StatusCode, Message: httpError.Message}
+		return httpResp.StatusCode, newHTTPError(req, httpResp)
 	}
 
 	if resp != nil {
@@ -92,17 +108,45 @@ func (c *Client) DoRequest(req *http.Request, resp interface{}) (statusCode int,
 	return httpResp.StatusCode, nil
 }
 
-type HTTPError struct {
-	Code    int
-	Message string
-}
+// do sends a request. When the API rejects the token and the token source is able to provide a new one,
+// e.g. because a short-lived token expired, the request is sent once more with the new token.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.readOnly && req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return nil, fmt.Errorf("%w: refusing to send %s %s", ErrReadOnly, req.Method, req.URL.Path)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	tokens, ok := c.tokens.(RefreshableTokenSource)
+	if !ok || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return resp, nil
+	}
+
+	stale := req.Header.Get("Circle-Token")
+	tokens.Invalidate(stale)
 
-func (e *HTTPError) Error() string {
-	if e.Code == 0 {
-		e.Code = http.StatusInternalServerError
+	token, err := tokens.Token(req.Context())
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("could not refresh the API token: %w", err)
 	}
-	if e.Message != "" {
-		return e.Message
+	if token == stale {
+		return resp, nil
 	}
-	return fmt.Sprintf("response %d (%s)", e.Code, http.StatusText(e.Code))
+	resp.Body.Close()
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Circle-Token", token)
+
+	log.Printf("[DEBUG] circleci: API token rejected, retrying with a new token method=%s url=%s", req.Method, req.URL)
+
+	return c.client.Do(retry)
 }
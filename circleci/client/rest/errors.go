@@ -0,0 +1,105 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Classes of API errors. An *HTTPError matches the class of its status code with errors.Is.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+)
+
+// ErrReadOnly is returned for requests which would modify CircleCI while the client is read-only
+var ErrReadOnly = errors.New("read-only mode")
+
+// requestIDHeaders are the response headers which may identify a request in CircleCI's logs
+var requestIDHeaders = []string{"X-Request-Id", "X-Amzn-Trace-Id"}
+
+// maxErrorBodySize bounds how much of a non-JSON error body is kept in the error message
+const maxErrorBodySize = 512
+
+// HTTPError is returned for API responses with an error status code
+type HTTPError struct {
+	Method    string
+	Path      string
+	Code      int
+	Message   string
+	RequestID string
+}
+
+func newHTTPError(req *http.Request, resp *http.Response) *HTTPError {
+	e := &HTTPError{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Code:   resp.StatusCode,
+	}
+
+	for _, header := range requestIDHeaders {
+		if id := resp.Header.Get(header); id != "" {
+			e.RequestID = id
+			break
+		}
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	// Errors are usually JSON, but proxies and load balancers may answer with HTML or plain text
+	apiError := struct {
+		Message string `json:"message"`
+	}{}
+	if err := json.Unmarshal(body, &apiError); err == nil {
+		e.Message = apiError.Message
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+		if len(e.Message) > maxErrorBodySize {
+			e.Message = e.Message[:maxErrorBodySize] + "..."
+		}
+	}
+
+	return e
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.Code, http.StatusText(e.Code))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request ID: %s)", e.RequestID)
+	}
+
+	return msg
+}
+
+// Is matches the error class of the status code
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.Code == http.StatusBadRequest || e.Code == http.StatusUnprocessableEntity
+	case ErrUnauthorized:
+		return e.Code == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.Code == http.StatusForbidden
+	case ErrNotFound:
+		return e.Code == http.StatusNotFound
+	case ErrConflict:
+		return e.Code == http.StatusConflict
+	case ErrRateLimited:
+		return e.Code == http.StatusTooManyRequests
+	case ErrServer:
+		return e.Code >= 500
+	}
+
+	return false
+}
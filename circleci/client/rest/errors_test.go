@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoRequestReturnsHTTPError(t *testing.T) {
+	cases := []struct {
+		Status   int
+		Body     string
+		Class    error
+		Expected string
+	}{
+		{
+			Status:   http.StatusNotFound,
+			Body:     `{"message": "Project not found"}`,
+			Class:    ErrNotFound,
+			Expected: "GET /api/v2/project/gh/org/repo: 404 Not Found: Project not found (request ID: abc-123)",
+		},
+		{
+			Status:   http.StatusBadGateway,
+			Body:     "<html><body>Bad Gateway</body></html>",
+			Class:    ErrServer,
+			Expected: "GET /api/v2/project/gh/org/repo: 502 Bad Gateway: <html><body>Bad Gateway</body></html> (request ID: abc-123)",
+		},
+		{
+			Status:   http.StatusForbidden,
+			Class:    ErrForbidden,
+			Expected: "GET /api/v2/project/gh/org/repo: 403 Forbidden (request ID: abc-123)",
+		},
+	}
+
+	for _, tc := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Request-Id", "abc-123")
+			w.WriteHeader(tc.Status)
+			_, _ = w.Write([]byte(tc.Body))
+		}))
+
+		client, err := New(server.URL, "/api/v2", StaticToken("token"), server.Client())
+		assert.NoError(t, err)
+
+		req, err := client.NewRequest(context.Background(), "GET", &url.URL{Path: "project/gh/org/repo"}, nil)
+		assert.NoError(t, err)
+
+		status, err := client.DoRequest(req, &struct{}{})
+		server.Close()
+
+		var httpError *HTTPError
+		assert.Equal(t, tc.Status, status)
+		assert.True(t, errors.As(err, &httpError))
+		assert.True(t, errors.Is(err, tc.Class))
+		assert.False(t, errors.Is(err, ErrUnauthorized))
+		assert.Equal(t, tc.Expected, err.Error())
+	}
+}
+
+func TestReadOnlyRefusesWrites(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "/api/v2", StaticToken("token"), server.Client())
+	assert.NoError(t, err)
+	client.SetReadOnly(true)
+
+	for _, method := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+		req, err := client.NewRequest(context.Background(), method, &url.URL{Path: "context"}, nil)
+		assert.NoError(t, err)
+
+		_, err = client.DoRequest(req, &struct{}{})
+		assert.True(t, errors.Is(err, ErrReadOnly))
+		assert.EqualError(t, err, "read-only mode: refusing to send "+method+" /api/v2/context")
+	}
+	assert.Equal(t, 0, calls)
+
+	req, err := client.NewRequest(context.Background(), "GET", &url.URL{Path: "context"}, nil)
+	assert.NoError(t, err)
+
+	_, err = client.DoRequest(req, &struct{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
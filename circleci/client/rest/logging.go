@@ -0,0 +1,178 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/logging"
+)
+
+const redacted = "(redacted)"
+
+// sensitiveHeaders are never logged in clear
+var sensitiveHeaders = map[string]bool{
+	"Circle-Token":  true,
+	"Authorization": true,
+}
+
+// sensitiveFields are the JSON fields whose values are never logged in clear, e.g. environment variable values
+var sensitiveFields = map[string]bool{
+	"value": true,
+	"token": true,
+}
+
+type loggingTransport struct {
+	next  http.RoundTripper
+	debug bool
+	trace bool
+}
+
+// NewLoggingTransport wraps an http.RoundTripper so that API traffic is logged according to TF_LOG.
+// At DEBUG, every attempt is logged with its method, URL, status, latency and retry count.
+// At TRACE, headers and bodies are logged as well. Tokens and environment variable values are redacted.
+func NewLoggingTransport(next http.RoundTripper) http.RoundTripper {
+	level := logging.LogLevel()
+
+	return &loggingTransport{
+		next:  next,
+		debug: level == "DEBUG" || level == "TRACE",
+		trace: level == "TRACE",
+	}
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.debug {
+		return t.next.RoundTrip(req)
+	}
+
+	attempt := attemptFromContext(req.Context())
+
+	if t.trace {
+		body, err := peekRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("[TRACE] circleci: request method=%s url=%s retry=%d headers=%s body=%s",
+			req.Method, req.URL, attempt, formatHeaders(req.Header), redactBody(body))
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	latency := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		log.Printf("[DEBUG] circleci: request failed method=%s url=%s retry=%d latency=%s error=%q",
+			req.Method, req.URL, attempt, latency, err)
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] circleci: response method=%s url=%s status=%d retry=%d latency=%s",
+		req.Method, req.URL, resp.StatusCode, attempt, latency)
+
+	if t.trace {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		log.Printf("[TRACE] circleci: response method=%s url=%s status=%d headers=%s body=%s",
+			req.Method, req.URL, resp.StatusCode, formatHeaders(resp.Header), redactBody(body))
+	}
+
+	return resp, nil
+}
+
+// peekRequestBody returns the request body without consuming it
+func peekRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+
+		return io.ReadAll(body)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+func formatHeaders(header http.Header) string {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := strings.Join(header[key], ",")
+		if sensitiveHeaders[http.CanonicalHeaderKey(key)] {
+			value = redacted
+		}
+
+		parts = append(parts, fmt.Sprintf("%s:%q", key, value))
+	}
+
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+// redactBody replaces the values of sensitive fields in a JSON body. Bodies which are not JSON are
+// logged as is, since the API only receives JSON payloads.
+func redactBody(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return `""`
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Sprintf("%q", body)
+	}
+
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return fmt.Sprintf("%q", body)
+	}
+
+	return string(out)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if sensitiveFields[strings.ToLower(key)] {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(value)
+		}
+	}
+
+	return v
+}
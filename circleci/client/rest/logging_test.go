@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactBody(t *testing.T) {
+	cases := []struct {
+		Body     string
+		Expected string
+	}{
+		{
+			Body:     `{"name": "AWS_SECRET_ACCESS_KEY", "value": "s3cr3t"}`,
+			Expected: `{"name":"AWS_SECRET_ACCESS_KEY","value":"(redacted)"}`,
+		},
+		{
+			Body:     `{"items": [{"name": "FOO", "value": "xxxxabcd"}], "next_page_token": null}`,
+			Expected: `{"items":[{"name":"FOO","value":"(redacted)"}],"next_page_token":null}`,
+		},
+		{
+			Body:     `{"Value": "s3cr3t"}`,
+			Expected: `{"Value":"(redacted)"}`,
+		},
+		{
+			Body:     `not json`,
+			Expected: `"not json"`,
+		},
+		{
+			Body:     ``,
+			Expected: `""`,
+		},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.Expected, redactBody([]byte(tc.Body)))
+	}
+}
+
+func TestFormatHeadersRedactsToken(t *testing.T) {
+	header := http.Header{}
+	header.Set("Circle-Token", "s3cr3t")
+	header.Set("Accept", "application/json")
+
+	assert.Equal(t, `{Accept:"application/json" Circle-Token:"(redacted)"}`, formatHeaders(header))
+}
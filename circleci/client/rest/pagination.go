@@ -0,0 +1,125 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// page is a single page of a paginated list endpoint of the REST API (v2)
+type page[T any] struct {
+	Items         []T     `json:"items"`
+	NextPageToken *string `json:"next_page_token"`
+}
+
+// Pager iterates over the items of a paginated list endpoint, following next_page_token
+// and fetching pages lazily as items are consumed:
+//
+//	pager := rest.NewPager[Context](ctx, c, u)
+//	for pager.Next() {
+//		ctx := pager.Item()
+//	}
+//	if err := pager.Err(); err != nil {
+//		...
+//	}
+type Pager[T any] struct {
+	ctx    context.Context
+	client *Client
+	url    *url.URL
+
+	items     []T
+	index     int
+	pageToken *string
+	started   bool
+	err       error
+}
+
+// NewPager returns a Pager over the list endpoint at u. Query parameters of u are kept for every page.
+func NewPager[T any](ctx context.Context, client *Client, u *url.URL) *Pager[T] {
+	return &Pager[T]{
+		ctx:    ctx,
+		client: client,
+		url:    u,
+		index:  -1,
+	}
+}
+
+// Next advances to the next item, fetching the next page if needed. It returns false once all
+// items have been consumed or when an error occurred.
+func (p *Pager[T]) Next() bool {
+	if p.err != nil {
+		return false
+	}
+
+	p.index++
+	for p.index >= len(p.items) {
+		if p.started && p.pageToken == nil {
+			return false
+		}
+
+		if err := p.fetch(); err != nil {
+			p.err = err
+			return false
+		}
+	}
+
+	return true
+}
+
+// Item returns the current item
+func (p *Pager[T]) Item() T {
+	return p.items[p.index]
+}
+
+// Err returns the error which stopped the iteration, if any
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+func (p *Pager[T]) fetch() error {
+	u := *p.url
+	query := u.Query()
+	if p.pageToken != nil {
+		query.Set("page-token", *p.pageToken)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := p.client.NewRequest(p.ctx, "GET", &u, nil)
+	if err != nil {
+		return err
+	}
+
+	resp := &page[T]{}
+	if _, err := p.client.DoRequest(req, resp); err != nil {
+		return err
+	}
+
+	next := resp.NextPageToken
+	if next != nil && *next == "" {
+		next = nil
+	}
+
+	// Guard against looping forever on an API which keeps returning the same page
+	if next != nil && p.pageToken != nil && *next == *p.pageToken {
+		return fmt.Errorf("pagination of %s did not advance past page token %q", p.url.Path, *next)
+	}
+
+	p.started = true
+	p.items = resp.Items
+	p.index = 0
+	p.pageToken = next
+
+	return nil
+}
+
+// ListAll fetches every page of the list endpoint at u and returns all items
+func ListAll[T any](ctx context.Context, client *Client, u *url.URL) ([]T, error) {
+	items := []T{}
+
+	pager := NewPager[T](ctx, client, u)
+	for pager.Next() {
+		items = append(items, pager.Item())
+	}
+
+	return items, pager.Err()
+}
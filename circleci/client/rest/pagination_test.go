@@ -0,0 +1,76 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testItem struct {
+	Name string `json:"name"`
+}
+
+func testPaginatedServer(t *testing.T, pages map[string]string) (*Client, *[]string) {
+	requested := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "ctx", r.URL.Query().Get("owner-id"))
+
+		token := r.URL.Query().Get("page-token")
+		requested = append(requested, token)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(pages[token]))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := New(server.URL, "/api/v2", StaticToken("token"), server.Client())
+	assert.NoError(t, err)
+
+	return client, &requested
+}
+
+func TestListAllFollowsNextPageToken(t *testing.T) {
+	client, requested := testPaginatedServer(t, map[string]string{
+		"":   `{"items": [{"name": "a"}, {"name": "b"}], "next_page_token": "p2"}`,
+		"p2": `{"items": [], "next_page_token": "p3"}`,
+		"p3": `{"items": [{"name": "c"}], "next_page_token": null}`,
+	})
+
+	items, err := ListAll[testItem](context.Background(), client, &url.URL{Path: "context", RawQuery: "owner-id=ctx"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []testItem{{Name: "a"}, {Name: "b"}, {Name: "c"}}, items)
+	assert.Equal(t, []string{"", "p2", "p3"}, *requested)
+}
+
+func TestPagerStopsEarly(t *testing.T) {
+	client, requested := testPaginatedServer(t, map[string]string{
+		"":   `{"items": [{"name": "a"}, {"name": "b"}], "next_page_token": "p2"}`,
+		"p2": `{"items": [{"name": "c"}]}`,
+	})
+
+	pager := NewPager[testItem](context.Background(), client, &url.URL{Path: "context", RawQuery: "owner-id=ctx"})
+	for pager.Next() {
+		if pager.Item().Name == "b" {
+			break
+		}
+	}
+
+	assert.NoError(t, pager.Err())
+	assert.Equal(t, []string{""}, *requested)
+}
+
+func TestPagerFailsOnRepeatedPageToken(t *testing.T) {
+	client, _ := testPaginatedServer(t, map[string]string{
+		"":   `{"items": [{"name": "a"}], "next_page_token": "p2"}`,
+		"p2": `{"items": [{"name": "b"}], "next_page_token": "p2"}`,
+	})
+
+	_, err := ListAll[testItem](context.Background(), client, &url.URL{Path: "context", RawQuery: "owner-id=ctx"})
+
+	assert.Error(t, err)
+}
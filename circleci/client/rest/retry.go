@@ -0,0 +1,212 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how failed requests are retried
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries for a single request. Zero disables retries.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, doubled for each subsequent one
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between two retries, including delays requested by the server
+	MaxBackoff time.Duration
+	// RetryNonIdempotent allows retrying POST and PATCH requests after server and network errors.
+	// Rate limited requests are always retried, since the server did not process them.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy is used when no explicit policy is configured
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 5,
+	MinBackoff: 1 * time.Second,
+	MaxBackoff: 30 * time.Second,
+}
+
+type attemptContextKey struct{}
+
+// attemptFromContext returns the number of retries which preceded the current attempt of a request
+func attemptFromContext(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptContextKey{}).(int)
+	return attempt
+}
+
+type retryTransport struct {
+	policy RetryPolicy
+	next   http.RoundTripper
+}
+
+// NewRetryTransport wraps an http.RoundTripper so that rate limited requests, server errors
+// and transient network errors are retried with an exponential backoff
+func NewRetryTransport(policy RetryPolicy, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &retryTransport{policy: policy, next: next}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		r, err := rewindRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		r = r.WithContext(context.WithValue(r.Context(), attemptContextKey{}, attempt))
+
+		resp, err := t.next.RoundTrip(r)
+		if attempt >= t.policy.MaxRetries || !t.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		wait := t.backoff(attempt, resp)
+		log.Printf("[DEBUG] circleci: retrying request method=%s url=%s retry=%d wait=%s reason=%q",
+			req.Method, req.URL, attempt+1, wait, retryReason(resp, err))
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// rewindRequest returns a copy of the request with a fresh body for every attempt after the first one
+func rewindRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, errors.New("cannot retry request with a non-rewindable body")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	r := req.Clone(req.Context())
+	r.Body = body
+
+	return r, nil
+}
+
+func (t *retryTransport) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	if !t.policy.RetryNonIdempotent && !isIdempotent(req.Method) {
+		return false
+	}
+
+	if err != nil {
+		var netErr net.Error
+		return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+	}
+
+	return resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented
+}
+
+func retryReason(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+
+	return resp.Status
+}
+
+// backoff returns the delay before the next attempt. Delays requested by the server through the
+// Retry-After and X-RateLimit-Reset headers take precedence over the exponential backoff. Both are
+// capped by MaxBackoff, so that a single response cannot stall a resource until its timeout.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := retryAfter(resp, time.Now()); ok {
+			if t.policy.MaxBackoff > 0 && wait > t.policy.MaxBackoff {
+				wait = t.policy.MaxBackoff
+			}
+			return wait
+		}
+	}
+
+	wait := t.policy.MinBackoff << uint(attempt)
+	if wait <= 0 || (t.policy.MaxBackoff > 0 && wait > t.policy.MaxBackoff) {
+		wait = t.policy.MaxBackoff
+	}
+
+	if wait <= 0 {
+		return 0
+	}
+
+	// Add jitter so that parallel requests which were throttled together do not retry together
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// retryAfter parses the delay requested by the server, if any
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+
+		if date, err := http.ParseTime(v); err == nil {
+			return nonNegative(date.Sub(now)), true
+		}
+	}
+
+	// Rate limit headers are sent with every response, they are only relevant once the limit is hit
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if v := resp.Header.Get("X-RateLimit-Reset"); v != "" {
+		// CircleCI sends the number of seconds until the limit resets
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
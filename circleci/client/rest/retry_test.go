@@ -0,0 +1,126 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRetryServer(t *testing.T, statuses ...int) (*httptest.Server, *int) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[len(statuses)-1]
+		if calls < len(statuses) {
+			status = statuses[calls]
+		}
+		calls++
+
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func testRetryClient(policy RetryPolicy) *http.Client {
+	return &http.Client{Transport: NewRetryTransport(policy, nil)}
+}
+
+func TestRetryTransportRetriesRateLimitedRequests(t *testing.T) {
+	server, calls := testRetryServer(t, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK)
+
+	req, _ := http.NewRequest("POST", server.URL, strings.NewReader(`{"name":"VAR"}`))
+	resp, err := testRetryClient(RetryPolicy{MaxRetries: 3}).Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, *calls)
+}
+
+func TestRetryTransportStopsAfterMaxRetries(t *testing.T) {
+	server, calls := testRetryServer(t, http.StatusServiceUnavailable)
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := testRetryClient(RetryPolicy{MaxRetries: 2}).Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 3, *calls)
+}
+
+func TestRetryTransportDoesNotRetryNonIdempotentRequests(t *testing.T) {
+	server, calls := testRetryServer(t, http.StatusInternalServerError, http.StatusOK)
+
+	req, _ := http.NewRequest("POST", server.URL, nil)
+	resp, err := testRetryClient(RetryPolicy{MaxRetries: 3}).Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, 1, *calls)
+
+	server, calls = testRetryServer(t, http.StatusInternalServerError, http.StatusOK)
+
+	req, _ = http.NewRequest("POST", server.URL, nil)
+	resp, err = testRetryClient(RetryPolicy{MaxRetries: 3, RetryNonIdempotent: true}).Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestRetryTransportDoesNotRetryClientErrors(t *testing.T) {
+	server, calls := testRetryServer(t, http.StatusNotFound, http.StatusOK)
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := testRetryClient(RetryPolicy{MaxRetries: 3}).Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		Status   int
+		Header   string
+		Value    string
+		Expected time.Duration
+		Found    bool
+	}{
+		{Status: 429, Header: "Retry-After", Value: "7", Expected: 7 * time.Second, Found: true},
+		{Status: 503, Header: "Retry-After", Value: now.Add(time.Minute).Format(http.TimeFormat), Expected: time.Minute, Found: true},
+		{Status: 429, Header: "X-RateLimit-Reset", Value: "12", Expected: 12 * time.Second, Found: true},
+		{Status: 500, Header: "X-RateLimit-Reset", Value: "12"},
+		{Status: 429, Header: "Retry-After", Value: "soon"},
+	}
+
+	for _, tc := range cases {
+		resp := &http.Response{StatusCode: tc.Status, Header: http.Header{}}
+		resp.Header.Set(tc.Header, tc.Value)
+
+		wait, found := retryAfter(resp, now)
+
+		assert.Equal(t, tc.Found, found, "%s: %s", tc.Header, tc.Value)
+		assert.Equal(t, tc.Expected, wait, "%s: %s", tc.Header, tc.Value)
+	}
+}
+
+func TestBackoffCapsRequestedDelays(t *testing.T) {
+	transport := &retryTransport{policy: RetryPolicy{MinBackoff: time.Second, MaxBackoff: 30 * time.Second}}
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("Retry-After", "3600")
+	assert.Equal(t, 30*time.Second, transport.backoff(0, resp))
+
+	resp.Header.Set("Retry-After", "7")
+	assert.Equal(t, 7*time.Second, transport.backoff(0, resp))
+}
@@ -0,0 +1,30 @@
+package rest
+
+import (
+	"context"
+)
+
+// TokenSource provides the API token sent with every request
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// RefreshableTokenSource is a TokenSource able to provide a new token when the API rejects the current one
+type RefreshableTokenSource interface {
+	TokenSource
+
+	// Invalidate discards the given token, so that the next call to Token provides a new one.
+	// It does nothing if the token has already been replaced.
+	Invalidate(token string)
+}
+
+type staticToken string
+
+// StaticToken returns a TokenSource which always provides the given token
+func StaticToken(token string) TokenSource {
+	return staticToken(token)
+}
+
+func (t staticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
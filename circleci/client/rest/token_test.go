@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rotatingToken struct {
+	tokens []string
+}
+
+func (t *rotatingToken) Token(context.Context) (string, error) {
+	return t.tokens[0], nil
+}
+
+func (t *rotatingToken) Invalidate(token string) {
+	if len(t.tokens) > 1 && t.tokens[0] == token {
+		t.tokens = t.tokens[1:]
+	}
+}
+
+func TestDoRequestRefreshesRejectedToken(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("Circle-Token"))
+		if r.Header.Get("Circle-Token") != "fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "/api/v2", &rotatingToken{tokens: []string{"expired", "fresh"}}, server.Client())
+	assert.NoError(t, err)
+
+	req, err := client.NewRequest(context.Background(), "POST", &url.URL{Path: "context"}, map[string]string{"name": "ctx"})
+	assert.NoError(t, err)
+
+	status, err := client.DoRequest(req, &struct{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"expired", "fresh"}, received)
+}
+
+func TestDoRequestDoesNotRetryStaticToken(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "/api/v2", StaticToken("invalid"), server.Client())
+	assert.NoError(t, err)
+
+	req, err := client.NewRequest(context.Background(), "GET", &url.URL{Path: "me"}, nil)
+	assert.NoError(t, err)
+
+	status, err := client.DoRequest(req, &struct{}{})
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, 1, calls)
+}
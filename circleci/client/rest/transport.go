@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportConfig configures how the provider connects to the CircleCI API
+type TransportConfig struct {
+	// ProxyURL is the proxy every request goes through. When empty, the proxy is taken from the
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+	ProxyURL string
+
+	// CACertPEM holds PEM-encoded certificates trusted in addition to the system ones
+	CACertPEM []byte
+
+	// ClientCertPEM and ClientKeyPEM hold the PEM-encoded certificate and key presented to the server
+	ClientCertPEM []byte
+	ClientKeyPEM  []byte
+
+	// InsecureSkipVerify disables the verification of the server certificate
+	InsecureSkipVerify bool
+}
+
+// NewTransport returns the base transport used to send requests to the CircleCI API.
+// It is meant to be shared by every client of the provider.
+func NewTransport(config TransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Bound every attempt rather than the whole request, which may span several retries
+	transport.ResponseHeaderTimeout = 30 * time.Second
+
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		if proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q: scheme and host are required", config.ProxyURL)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if len(config.CACertPEM) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(config.CACertPEM) {
+			return nil, errors.New("no valid PEM-encoded certificate found in the CA bundle")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(config.ClientCertPEM) > 0 || len(config.ClientKeyPEM) > 0 {
+		if len(config.ClientCertPEM) == 0 || len(config.ClientKeyPEM) == 0 {
+			return nil, errors.New("both a client certificate and a client key are required")
+		}
+
+		certificate, err := tls.X509KeyPair(config.ClientCertPEM, config.ClientKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
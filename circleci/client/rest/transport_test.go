@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTransportTrustsCACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	transport, err := NewTransport(TransportConfig{})
+	assert.NoError(t, err)
+	_, err = (&http.Client{Transport: transport}).Get(server.URL)
+	assert.Error(t, err)
+
+	caCertPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	transport, err = NewTransport(TransportConfig{CACertPEM: caCertPEM})
+	assert.NoError(t, err)
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	transport, err = NewTransport(TransportConfig{InsecureSkipVerify: true})
+	assert.NoError(t, err)
+	resp, err = (&http.Client{Transport: transport}).Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestNewTransportUsesProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	transport, err := NewTransport(TransportConfig{ProxyURL: proxy.URL})
+	assert.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get("http://circleci.example.com/api/v2/me")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "http://circleci.example.com/api/v2/me", proxied)
+}
+
+func TestNewTransportRejectsInvalidConfig(t *testing.T) {
+	cases := []TransportConfig{
+		{ProxyURL: "proxy.example.com:3128"},
+		{CACertPEM: []byte("not a certificate")},
+		{ClientCertPEM: []byte("-----BEGIN CERTIFICATE-----")},
+		{ClientCertPEM: []byte("not a certificate"), ClientKeyPEM: []byte("not a key")},
+	}
+
+	for _, tc := range cases {
+		_, err := NewTransport(tc)
+		assert.Error(t, err)
+	}
+}
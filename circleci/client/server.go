@@ -0,0 +1,86 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
+)
+
+// ServerVersion is the version of a self-hosted CircleCI server installation
+type ServerVersion struct {
+	Major, Minor, Patch int
+}
+
+// ParseServerVersion parses a version such as 3.4 or 4.1.2. An empty version stands for CircleCI cloud.
+func ParseServerVersion(version string) (*ServerVersion, error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if version == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("invalid server version %q, expected MAJOR.MINOR[.PATCH]", version)
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid server version %q, expected MAJOR.MINOR[.PATCH]", version)
+		}
+		numbers[i] = n
+	}
+
+	return &ServerVersion{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+func (v ServerVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+func (v ServerVersion) atLeast(other ServerVersion) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+
+	return v.Patch >= other.Patch
+}
+
+// feature is a set of API endpoints, which may not be available on older server versions
+type feature struct {
+	name string
+	// since is the first server version serving the feature on API v2
+	since ServerVersion
+	// v1 is whether API v1.1 serves the feature with the same endpoints, for older server versions
+	v1 bool
+}
+
+var (
+	featureProjects = feature{name: "projects", since: ServerVersion{Major: 3}}
+	featureUsers    = feature{name: "users and collaborations", since: ServerVersion{Major: 3}}
+	featureContexts = feature{name: "contexts", since: ServerVersion{Major: 3}}
+
+	featureProjectEnvironmentVariables = feature{name: "project environment variables", since: ServerVersion{Major: 3}, v1: true}
+	featureCheckoutKeys                = feature{name: "checkout keys", since: ServerVersion{Major: 3}, v1: true}
+)
+
+// api returns the REST client serving a feature on the configured server version. Older server versions
+// are served by API v1.1 where it provides the same endpoints, and fail with ErrUnsupported otherwise.
+func (c *Client) api(f feature) (*rest.Client, error) {
+	if c.serverVersion == nil || c.serverVersion.atLeast(f.since) {
+		return c.rest, nil
+	}
+
+	if f.v1 {
+		return c.restV1, nil
+	}
+
+	return nil, fmt.Errorf("%s are %w: CircleCI server %s, %d.%d or later is required",
+		f.name, ErrUnsupported, c.serverVersion, f.since.Major, f.since.Minor)
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	cases := []struct {
+		Version  string
+		Expected *ServerVersion
+		Error    bool
+	}{
+		{Version: ""},
+		{Version: "3.4", Expected: &ServerVersion{Major: 3, Minor: 4}},
+		{Version: "v4.1.2", Expected: &ServerVersion{Major: 4, Minor: 1, Patch: 2}},
+		{Version: "4.x", Error: true},
+		{Version: "-1", Error: true},
+	}
+
+	for _, tc := range cases {
+		version, err := ParseServerVersion(tc.Version)
+		assert.Equal(t, tc.Error, err != nil, tc.Version)
+		assert.Equal(t, tc.Expected, version, tc.Version)
+	}
+}
+
+func TestOlderServerVersions(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		if r.Method == "POST" {
+			_, _ = w.Write([]byte(`{"type": "github-user-key", "fingerprint": "aa:bb", "time": "2015-09-21T17:29:21.042Z"}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(Config{
+		URL:           server.URL + "/api/v2/",
+		VCS:           "github",
+		Organization:  "org",
+		ServerVersion: "2.19",
+	})
+	assert.NoError(t, err)
+
+	_, err = c.GetContextByName(context.Background(), "ctx")
+	assert.True(t, errors.Is(err, ErrUnsupported))
+	assert.EqualError(t, err, "contexts are not supported on this server version: CircleCI server 2.19.0, 3.0 or later is required")
+
+	assert.NoError(t, c.CreateProjectEnvironmentVariable(context.Background(), "repo", "FOO", "bar"))
+
+	key, err := c.CreateCheckoutKey(context.Background(), "repo", "user-key")
+	assert.NoError(t, err)
+	assert.Equal(t, "user-key", key.Type)
+	assert.Equal(t, "2015-09-21T17:29:21.042Z", key.CreatedAt)
+
+	assert.Equal(t, []string{
+		"POST /api/v1.1/project/github/org/repo/envvar",
+		"POST /api/v1.1/project/github/org/repo/checkout-key",
+	}, paths)
+}
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
+
+	"github.com/google/uuid"
+)
+
+// VCS types as they appear in slugs
+const (
+	VCSGitHub    = "gh"
+	VCSBitbucket = "bb"
+	// VCSCircleCI is the VCS type of GitLab and GitHub App organizations, whose slugs are made of IDs
+	VCSCircleCI = "circleci"
+)
+
+// vcsAliases maps the VCS types accepted in the configuration to their form in slugs
+var vcsAliases = map[string]string{
+	"gh":        VCSGitHub,
+	"github":    VCSGitHub,
+	"bb":        VCSBitbucket,
+	"bitbucket": VCSBitbucket,
+	"circleci":  VCSCircleCI,
+	"gitlab":    VCSCircleCI,
+}
+
+// vcsV1 maps VCS types to their form in API v1.1 slugs
+var vcsV1 = map[string]string{
+	VCSGitHub:    "github",
+	VCSBitbucket: "bitbucket",
+}
+
+// VCSTypes returns the VCS types accepted in the configuration
+func VCSTypes() []string {
+	return []string{"github", "gh", "bitbucket", "bb", "gitlab", "circleci"}
+}
+
+// NormalizeVCS returns the form of a VCS type used in slugs, e.g. gh for github
+func NormalizeVCS(vcs string) (string, error) {
+	normalized, ok := vcsAliases[strings.ToLower(vcs)]
+	if !ok {
+		return "", fmt.Errorf("unsupported VCS type %q, expected one of %s", vcs, strings.Join(VCSTypes(), ", "))
+	}
+
+	return normalized, nil
+}
+
+// ProjectSlug identifies a project, e.g. gh/org/repo, or circleci/<org-id>/<project-id> for GitLab
+// and GitHub App organizations
+type ProjectSlug struct {
+	VCS          string
+	Organization string
+	Project      string
+}
+
+// IsProjectSlug returns whether a project is referenced by a fully qualified slug rather than by its name
+func IsProjectSlug(project string) bool {
+	return strings.Contains(project, "/")
+}
+
+// ParseProjectSlug parses a fully qualified project slug. The VCS type may be given in its long form.
+func ParseProjectSlug(slug string) (*ProjectSlug, error) {
+	parts := strings.Split(slug, "/")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("invalid project slug %q, expected VCS/ORGANIZATION/PROJECT", slug)
+	}
+
+	vcs, err := NormalizeVCS(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid project slug %q: %w", slug, err)
+	}
+
+	return &ProjectSlug{VCS: vcs, Organization: parts[1], Project: parts[2]}, nil
+}
+
+func (s ProjectSlug) String() string {
+	return fmt.Sprintf("%s/%s/%s", s.VCS, s.Organization, s.Project)
+}
+
+// v1 returns the slug in the form expected by API v1.1
+func (s ProjectSlug) v1() string {
+	vcs, ok := vcsV1[s.VCS]
+	if !ok {
+		vcs = s.VCS
+	}
+
+	return fmt.Sprintf("%s/%s/%s", vcs, s.Organization, s.Project)
+}
+
+// ProjectSlug resolves a project, given either by its name in the configured organization or by a fully
+// qualified slug. In GitLab and GitHub App organizations, projects are referenced by their ID and the
+// organization name is resolved to its ID.
+func (c *Client) ProjectSlug(ctx context.Context, project string) (*ProjectSlug, error) {
+	if IsProjectSlug(project) {
+		return ParseProjectSlug(project)
+	}
+
+	if project == "" {
+		return nil, fmt.Errorf("a project name or slug is required")
+	}
+
+	if c.vcs != VCSCircleCI {
+		return &ProjectSlug{VCS: c.vcs, Organization: c.organization, Project: project}, nil
+	}
+
+	if _, err := uuid.Parse(project); err != nil {
+		return nil, fmt.Errorf("projects of %s organizations are referenced by their ID or by a circleci/<org-id>/<project-id> slug, got %q", c.vcs, project)
+	}
+
+	organizationID, err := c.OrganizationID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProjectSlug{VCS: c.vcs, Organization: organizationID, Project: project}, nil
+}
+
+// Slug returns the slug of a project, given either by its name in the configured organization or by a
+// fully qualified slug
+func (c *Client) Slug(ctx context.Context, project string) (string, error) {
+	slug, err := c.ProjectSlug(ctx, project)
+	if err != nil {
+		return "", err
+	}
+
+	return slug.String(), nil
+}
+
+// slugPath returns the form of a project slug expected by the given API
+func (c *Client) slugPath(api *rest.Client, slug *ProjectSlug) string {
+	if api == c.restV1 {
+		return slug.v1()
+	}
+
+	return slug.String()
+}
+
+// OrganizationSlug returns the slug of the configured organization, e.g. gh/org
+func (c *Client) OrganizationSlug() string {
+	return fmt.Sprintf("%s/%s", c.vcs, c.organization)
+}
+
+// OrganizationID returns the ID of the configured organization. Unless the organization is configured
+// by its ID, it is resolved from the organizations the API token has access to, and cached.
+func (c *Client) OrganizationID(ctx context.Context) (_ string, err error) {
+	if _, err := uuid.Parse(c.organization); err == nil {
+		return c.organization, nil
+	}
+
+	ctx, span := startSpan(ctx, "OrganizationID")
+	defer func() { endSpan(span, err) }()
+
+	v, err := c.cache.load(ctx, organizationIDKey(c.vcs, c.organization), func(ctx context.Context) (interface{}, error) {
+		collaborations, err := c.ListCollaborations(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, collaboration := range collaborations {
+			if collaboration.matches(c.vcs, c.organization) {
+				return collaboration.ID, nil
+			}
+		}
+
+		return nil, fmt.Errorf("could not resolve the ID of organization %q: %w", c.OrganizationSlug(), ErrNotFound)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return v.(string), nil
+}
+
+// matches returns whether the collaboration is the given organization, referenced by its name or ID
+func (collaboration Collaboration) matches(vcs, organization string) bool {
+	if strings.EqualFold(collaboration.Slug, fmt.Sprintf("%s/%s", vcs, organization)) {
+		return true
+	}
+
+	// Slugs of GitLab and GitHub App organizations contain their ID rather than their name
+	return strings.HasPrefix(collaboration.Slug, vcs+"/") &&
+		(strings.EqualFold(collaboration.Name, organization) || strings.EqualFold(collaboration.ID, organization))
+}
+
+func organizationIDKey(vcs, organization string) string {
+	return fmt.Sprintf("organization-id/%s/%s", vcs, organization)
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectSlug(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`[
+			{"id": "8c2b1a4e-0f5e-4c3a-9d7e-1a2b3c4d5e6f", "vcs-type": "circleci", "name": "my-gitlab-org", "slug": "circleci/8c2b1a4e-0f5e-4c3a-9d7e-1a2b3c4d5e6f"}
+		]`))
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		VCS          string
+		Organization string
+		Project      string
+		Expected     string
+		Error        bool
+	}{
+		{VCS: "github", Organization: "org", Project: "repo", Expected: "gh/org/repo"},
+		{VCS: "bb", Organization: "org", Project: "repo", Expected: "bb/org/repo"},
+		{VCS: "github", Organization: "org", Project: "bitbucket/other/repo", Expected: "bb/other/repo"},
+		{VCS: "github", Organization: "org", Project: "circleci/8c2b1a4e-0f5e-4c3a-9d7e-1a2b3c4d5e6f/1f2e3d4c-5b6a-4978-8695-a4b3c2d1e0f9", Expected: "circleci/8c2b1a4e-0f5e-4c3a-9d7e-1a2b3c4d5e6f/1f2e3d4c-5b6a-4978-8695-a4b3c2d1e0f9"},
+		{VCS: "gitlab", Organization: "my-gitlab-org", Project: "1f2e3d4c-5b6a-4978-8695-a4b3c2d1e0f9", Expected: "circleci/8c2b1a4e-0f5e-4c3a-9d7e-1a2b3c4d5e6f/1f2e3d4c-5b6a-4978-8695-a4b3c2d1e0f9"},
+		{VCS: "circleci", Organization: "my-gitlab-org", Project: "repo", Error: true},
+		{VCS: "github", Organization: "org", Project: "org/repo", Error: true},
+		{VCS: "github", Organization: "org", Project: "svn/org/repo", Error: true},
+	}
+
+	for _, tc := range cases {
+		c, err := New(Config{URL: server.URL + "/api/v2/", VCS: tc.VCS, Organization: tc.Organization})
+		assert.NoError(t, err)
+
+		slug, err := c.Slug(context.Background(), tc.Project)
+		assert.Equal(t, tc.Error, err != nil, tc.Project)
+		assert.Equal(t, tc.Expected, slug, tc.Project)
+	}
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestProjectSlugV1(t *testing.T) {
+	slug, err := ParseProjectSlug("gh/org/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, "github/org/repo", slug.v1())
+}
+
+func TestWithOrganization(t *testing.T) {
+	c, err := New(Config{URL: "https://circleci.com/api/v2/", VCS: "github", Organization: "org"})
+	assert.NoError(t, err)
+
+	other, err := c.WithOrganization("bitbucket", "other")
+	assert.NoError(t, err)
+
+	slug, _ := other.Slug(context.Background(), "repo")
+	assert.Equal(t, "bb/other/repo", slug)
+	assert.Equal(t, "bb/other", other.OrganizationSlug())
+	assert.True(t, c.cache == other.cache)
+
+	// The original client is left untouched
+	slug, _ = c.Slug(context.Background(), "repo")
+	assert.Equal(t, "gh/org/repo", slug)
+
+	other, err = c.WithOrganization("", "other")
+	assert.NoError(t, err)
+	assert.Equal(t, "gh/other", other.OrganizationSlug())
+
+	_, err = c.WithOrganization("svn", "")
+	assert.Error(t, err)
+}
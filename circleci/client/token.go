@@ -0,0 +1,140 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
+
+	"gopkg.in/yaml.v3"
+)
+
+// reloadingTokenSource caches the token it fetches until the API rejects it
+type reloadingTokenSource struct {
+	fetch func(ctx context.Context) (string, error)
+
+	mu    sync.Mutex
+	token string
+}
+
+func (s *reloadingTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" {
+		return s.token, nil
+	}
+
+	token, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	s.token = token
+
+	return token, nil
+}
+
+func (s *reloadingTokenSource) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Concurrent requests rejected with the same token only trigger a single reload
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+// FileTokenSource returns a TokenSource reading the token from a file. The file is read again
+// when the API rejects the token, so that it can be rotated by an external process.
+func FileTokenSource(path string) rest.RefreshableTokenSource {
+	return &reloadingTokenSource{
+		fetch: func(context.Context) (string, error) {
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("could not read the API token file: %w", err)
+			}
+
+			token := strings.TrimSpace(string(content))
+			if token == "" {
+				return "", fmt.Errorf("the API token file %s is empty", path)
+			}
+
+			return token, nil
+		},
+	}
+}
+
+// CommandTokenSource returns a TokenSource running a command which prints the token on its standard
+// output. The command is run again when the API rejects the token, e.g. once a short-lived token expired.
+func CommandTokenSource(args []string) rest.RefreshableTokenSource {
+	return &reloadingTokenSource{
+		fetch: func(ctx context.Context) (string, error) {
+			if len(args) == 0 {
+				return "", errors.New("no API token command configured")
+			}
+
+			var stdout, stderr bytes.Buffer
+			cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+
+			if err := cmd.Run(); err != nil {
+				if message := strings.TrimSpace(stderr.String()); message != "" {
+					return "", fmt.Errorf("API token command %q failed: %w: %s", args[0], err, message)
+				}
+				return "", fmt.Errorf("API token command %q failed: %w", args[0], err)
+			}
+
+			token := strings.TrimSpace(stdout.String())
+			if token == "" {
+				return "", fmt.Errorf("API token command %q printed no token", args[0])
+			}
+
+			return token, nil
+		},
+	}
+}
+
+// cliConfig is the subset of the circleci CLI configuration used by the provider
+type cliConfig struct {
+	Token string `yaml:"token"`
+}
+
+// CLIConfigPath returns the path of the circleci CLI configuration, ~/.circleci/cli.yml
+func CLIConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".circleci", "cli.yml"), nil
+}
+
+// CLIConfigToken returns the token stored in the circleci CLI configuration by `circleci setup`
+func CLIConfigToken(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var config cliConfig
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return "", fmt.Errorf("could not parse %s: %w", path, err)
+	}
+
+	token := strings.TrimSpace(config.Token)
+	if token == "" {
+		return "", fmt.Errorf("no token found in %s", path)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileTokenSourceReloadsRejectedToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("first\n"), 0o600))
+
+	tokens := FileTokenSource(path)
+	token, err := tokens.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "first", token)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("second\n"), 0o600))
+
+	// The token is cached until it gets rejected
+	token, _ = tokens.Token(context.Background())
+	assert.Equal(t, "first", token)
+
+	tokens.Invalidate("first")
+	token, err = tokens.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "second", token)
+
+	// A stale rejection does not discard the new token
+	tokens.Invalidate("first")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(""), 0o600))
+	token, err = tokens.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "second", token)
+}
+
+func TestCommandTokenSource(t *testing.T) {
+	token, err := CommandTokenSource([]string{"echo", "s3cr3t"}).Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", token)
+
+	_, err = CommandTokenSource([]string{"sh", "-c", "echo denied >&2; exit 1"}).Token(context.Background())
+	assert.EqualError(t, err, `API token command "sh" failed: exit status 1: denied`)
+
+	_, err = CommandTokenSource([]string{"true"}).Token(context.Background())
+	assert.EqualError(t, err, `API token command "true" printed no token`)
+}
+
+func TestCLIConfigToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cli.yml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("host: https://circleci.com\ntoken: s3cr3t\n"), 0o600))
+
+	token, err := CLIConfigToken(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", token)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("host: https://circleci.com\n"), 0o600))
+	_, err = CLIConfigToken(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+
+	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attributes describing the CircleCI objects an operation acts on
+const (
+	attributeProjectSlug  = attribute.Key("circleci.project.slug")
+	attributeContextID    = attribute.Key("circleci.context.id")
+	attributeContextName  = attribute.Key("circleci.context.name")
+	attributeVariableName = attribute.Key("circleci.variable.name")
+)
+
+// startSpan starts the span of a client operation. HTTP requests sent by the operation are recorded as its children.
+func startSpan(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(rest.TracerName).Start(ctx, "circleci."+operation, trace.WithAttributes(attributes...))
+}
+
+// endSpan ends the span of a client operation, recording its error if any
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// User is the user owning the API token
+type User struct {
+	ID    string `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+// Collaboration is an organization the user owning the API token has access to
+type Collaboration struct {
+	ID      string `json:"id"`
+	VCSType string `json:"vcs-type"`
+	Name    string `json:"name"`
+	Slug    string `json:"slug"`
+}
+
+// GetCurrentUser gets the user owning the API token
+func (c *Client) GetCurrentUser(ctx context.Context) (_ *User, err error) {
+	ctx, span := startSpan(ctx, "GetCurrentUser")
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := api.NewRequest(ctx, "GET", &url.URL{Path: "me"}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &User{}
+	if _, err := api.DoRequest(req, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ListCollaborations lists the organizations the user owning the API token has access to
+func (c *Client) ListCollaborations(ctx context.Context) (_ []Collaboration, err error) {
+	ctx, span := startSpan(ctx, "ListCollaborations")
+	defer func() { endSpan(span, err) }()
+
+	api, err := c.api(featureUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := api.NewRequest(ctx, "GET", &url.URL{Path: "me/collaborations"}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var collaborations []Collaboration
+	if _, err := api.DoRequest(req, &collaborations); err != nil {
+		return nil, err
+	}
+
+	return collaborations, nil
+}
+
+// ValidateCredentials checks that the API token is valid and grants access to the configured organization
+func (c *Client) ValidateCredentials(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "ValidateCredentials")
+	defer func() { endSpan(span, err) }()
+
+	if _, err := c.api(featureUsers); err != nil {
+		// API v1.1 only tells whether the token is valid
+		req, err := c.restV1.NewRequest(ctx, "GET", &url.URL{Path: "me"}, nil)
+		if err != nil {
+			return err
+		}
+
+		_, err = c.restV1.DoRequest(req, &User{})
+		return err
+	}
+
+	user, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	collaborations, err := c.ListCollaborations(ctx)
+	if err != nil {
+		return err
+	}
+
+	slug := c.OrganizationSlug()
+
+	accessible := make([]string, 0, len(collaborations))
+	for _, collaboration := range collaborations {
+		if collaboration.matches(c.vcs, c.organization) {
+			return nil
+		}
+
+		accessible = append(accessible, collaboration.Slug)
+	}
+	sort.Strings(accessible)
+
+	if len(accessible) == 0 {
+		return fmt.Errorf("user %q has no access to organization %q, nor to any other organization", user.Login, slug)
+	}
+
+	return fmt.Errorf("user %q has no access to organization %q, only to: %s", user.Login, slug, strings.Join(accessible, ", "))
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Circle-Token") != "valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "You must log in first."}`))
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v2/me":
+			_, _ = w.Write([]byte(`{"id": "1", "login": "octocat", "name": "Octo Cat"}`))
+		case "/api/v2/me/collaborations":
+			_, _ = w.Write([]byte(`[
+				{"id": "2", "vcs-type": "github", "name": "MyOrg", "slug": "gh/MyOrg"},
+				{"id": "3", "vcs-type": "bitbucket", "name": "other", "slug": "bb/other"}
+			]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		Token        string
+		VCS          string
+		Organization string
+		Expected     string
+	}{
+		{Token: "valid", VCS: "github", Organization: "myorg"},
+		{Token: "valid", VCS: "bb", Organization: "other"},
+		{
+			Token:        "valid",
+			VCS:          "bitbucket",
+			Organization: "MyOrg",
+			Expected:     `user "octocat" has no access to organization "bb/MyOrg", only to: bb/other, gh/MyOrg`,
+		},
+		{Token: "expired", VCS: "github", Organization: "MyOrg", Expected: "GET /api/v2/me: 401 Unauthorized: You must log in first."},
+	}
+
+	for _, tc := range cases {
+		c, err := New(Config{
+			URL:          server.URL + "/api/v2/",
+			Token:        rest.StaticToken(tc.Token),
+			VCS:          tc.VCS,
+			Organization: tc.Organization,
+		})
+		assert.NoError(t, err)
+
+		err = c.ValidateCredentials(context.Background())
+		if tc.Expected == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tc.Expected)
+		}
+	}
+
+	c, _ := New(Config{URL: server.URL + "/api/v2/", Token: rest.StaticToken("expired")})
+	assert.True(t, errors.Is(c.ValidateCredentials(context.Background()), ErrUnauthorized))
+}
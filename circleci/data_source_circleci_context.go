@@ -1,7 +1,8 @@
 package circleci
 
 import (
-	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+	"fmt"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 )
@@ -10,7 +11,13 @@ func dataSourceCircleCIContext() *schema.Resource {
 	return &schema.Resource{
 		Read: dataSourceCircleCIContextRead,
 
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(5 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
+			"organization": dataSourceOrganizationSchema(),
+			"vcs_type":     dataSourceVCSTypeSchema(),
 			"name": {
 				Type:        schema.TypeString,
 				Required:    true,
@@ -21,15 +28,21 @@ func dataSourceCircleCIContext() *schema.Resource {
 }
 
 func dataSourceCircleCIContextRead(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_context", schema.TimeoutRead)
+	defer cancel()
 
 	name := d.Get("name").(string)
 
-	ctx, err := c.GetContextByName(name)
+	circleContext, err := c.GetContextByName(ctx, name)
 	if err != nil {
-		return err
+		return describeAPIError(err, fmt.Sprintf("context %q", name))
 	}
 
-	d.SetId(ctx.ID)
+	d.SetId(circleContext.ID)
 	return nil
 }
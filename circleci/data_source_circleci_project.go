@@ -1,7 +1,8 @@
 package circleci
 
 import (
-	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+	"fmt"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 )
@@ -10,24 +11,36 @@ func dataSourceCircleCIProject() *schema.Resource {
 	return &schema.Resource{
 		Read: dataSourceCircleCIProjectRead,
 
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(5 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
+			"organization": dataSourceOrganizationSchema(),
+			"vcs_type":     dataSourceVCSTypeSchema(),
 			"name": {
 				Type:        schema.TypeString,
 				Required:    true,
-				Description: "The name of the project",
+				Description: "The name of the project, or its slug, e.g. `gh/org/repo` or `circleci/<org-id>/<project-id>`",
 			},
 		},
 	}
 }
 
 func dataSourceCircleCIProjectRead(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_project", schema.TimeoutRead)
+	defer cancel()
 
 	name := d.Get("name").(string)
 
-	project, err := c.GetProject(name)
+	project, err := c.GetProject(ctx, name)
 	if err != nil {
-		return err
+		return describeAPIError(err, fmt.Sprintf("project %q", name))
 	}
 
 	d.SetId(project.ID)
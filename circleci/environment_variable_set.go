@@ -0,0 +1,212 @@
+package circleci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// variableStore reads and writes the environment variables of their owner, a project or a context
+type variableStore interface {
+	// list returns the variables of the owner, by name, with the value returned by the API for them
+	list(ctx context.Context) (map[string]string, error)
+	// store creates or updates a variable
+	store(ctx context.Context, name, value string) error
+	// remove deletes a variable, without failing when it is already gone
+	remove(ctx context.Context, name string) error
+	// target names the owner in error messages, e.g. `context "my-context"`
+	target() string
+}
+
+// variableSet builds the resources managing all the environment variables of an owner, identified by the
+// owner attribute, e.g. project. The owner's variables are accessed through the store returned by newStore.
+type variableSet struct {
+	resourceType string
+	owner        string
+	newStore     func(c *client.Client, owner string) variableStore
+}
+
+func (v variableSet) resource(ownerSchema *schema.Schema) *schema.Resource {
+	return &schema.Resource{
+		Create: v.create,
+		Read:   v.read,
+		Update: v.update,
+		Delete: v.delete,
+		Importer: &schema.ResourceImporter{
+			State: v.importState,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"organization":    organizationSchema(),
+			"vcs_type":        vcsTypeSchema(),
+			v.owner:           ownerSchema,
+			"variables":       variablesSchema(),
+			"ignore_patterns": ignorePatternsSchema(),
+		},
+	}
+}
+
+func (v variableSet) create(d *schema.ResourceData, m interface{}) error {
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, v.resourceType, schema.TimeoutCreate)
+	defer cancel()
+
+	owner := d.Get(v.owner).(string)
+	store := v.newStore(c, owner)
+	variables := d.Get("variables").(map[string]interface{})
+
+	existing, err := store.list(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list environment variables: %w", describeAPIError(err, store.target()))
+	}
+
+	// Variables already in the owner are deleted in the same operation, unless they are declared or ignored
+	current := map[string]interface{}{}
+	for name := range existing {
+		if !matchesAnyPattern(name, d.Get("ignore_patterns").([]interface{})) {
+			current[name] = ""
+		}
+	}
+
+	upserts, deletes := variableChanges(current, variables, nil)
+	if err := applyVariableChanges(ctx, store.target(), upserts, deletes, store.store, store.remove); err != nil {
+		return err
+	}
+
+	d.SetId(owner)
+	_ = d.Set("variables", storedVariables(variables, upserts))
+
+	return v.read(d, m)
+}
+
+func (v variableSet) read(d *schema.ResourceData, m interface{}) error {
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, v.resourceType, schema.TimeoutRead)
+	defer cancel()
+
+	setOrganization(d, c)
+
+	store := v.newStore(c, d.Get(v.owner).(string))
+
+	variables, err := store.list(ctx)
+	if err != nil {
+		if errors.Is(err, client.ErrNotFound) {
+			d.SetId("")
+			return nil
+		}
+
+		return fmt.Errorf("failed to list environment variables: %w", describeAPIError(err, store.target()))
+	}
+
+	known := d.Get("variables").(map[string]interface{})
+	patterns := d.Get("ignore_patterns").([]interface{})
+
+	// Variables missing from the owner are dropped, so that they get created again. Undeclared variables
+	// are recorded with the value returned by the API, masked or empty, so that they show up as drift and
+	// get deleted.
+	state := map[string]string{}
+	for name, value := range variables {
+		if knownValue, ok := known[name]; ok {
+			state[name] = knownValue.(string)
+		} else if !matchesAnyPattern(name, patterns) {
+			state[name] = value
+		}
+	}
+
+	_ = d.Set("variables", state)
+
+	return nil
+}
+
+func (v variableSet) update(d *schema.ResourceData, m interface{}) (err error) {
+	defer keepStateOnError(d, &err)
+
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, v.resourceType, schema.TimeoutUpdate)
+	defer cancel()
+
+	store := v.newStore(c, d.Get(v.owner).(string))
+
+	old, new := d.GetChange("variables")
+	upserts, deletes := variableChanges(old.(map[string]interface{}), new.(map[string]interface{}), d.Get("ignore_patterns").([]interface{}))
+
+	if err := applyVariableChanges(ctx, store.target(), upserts, deletes, store.store, store.remove); err != nil {
+		return err
+	}
+
+	_ = d.Set("variables", storedVariables(new.(map[string]interface{}), upserts))
+
+	return v.read(d, m)
+}
+
+func (v variableSet) delete(d *schema.ResourceData, m interface{}) error {
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, v.resourceType, schema.TimeoutDelete)
+	defer cancel()
+
+	store := v.newStore(c, d.Get(v.owner).(string))
+
+	_, deletes := variableChanges(d.Get("variables").(map[string]interface{}), map[string]interface{}{}, nil)
+	if err := applyVariableChanges(ctx, store.target(), nil, deletes, store.store, store.remove); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func (v variableSet) importState(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	importOrganization(d)
+
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := operationContext(c, d, v.resourceType, operationImport)
+	defer cancel()
+
+	setOrganization(d, c)
+
+	owner := d.Id()
+	store := v.newStore(c, owner)
+
+	// Values cannot be read, so every variable is imported as undeclared and set again by the next apply
+	variables, err := store.list(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not import environment variables: %w", describeAPIError(err, store.target()))
+	}
+
+	_ = d.Set(v.owner, owner)
+	_ = d.Set("variables", variables)
+
+	return []*schema.ResourceData{d}, nil
+}
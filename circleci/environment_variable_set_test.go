@@ -0,0 +1,248 @@
+package circleci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVariableStore keeps variables in memory, listing them with a masked value, and records the writes
+type fakeVariableStore struct {
+	mutex     sync.Mutex
+	variables map[string]string
+	stored    map[string]string
+	removed   []string
+	// failStores rejects the values written to the variables
+	failStores bool
+}
+
+func newFakeVariableStore(names ...string) *fakeVariableStore {
+	s := &fakeVariableStore{variables: map[string]string{}, stored: map[string]string{}}
+	for _, name := range names {
+		s.variables[name] = "xxxx" + name
+	}
+
+	return s
+}
+
+func (s *fakeVariableStore) list(ctx context.Context) (map[string]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	variables := make(map[string]string, len(s.variables))
+	for name, value := range s.variables {
+		variables[name] = value
+	}
+
+	return variables, nil
+}
+
+func (s *fakeVariableStore) store(ctx context.Context, name, value string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.failStores {
+		return errors.New("invalid value")
+	}
+
+	s.variables[name] = "xxxx" + name
+	s.stored[name] = value
+	return nil
+}
+
+func (s *fakeVariableStore) remove(ctx context.Context, name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.variables, name)
+	s.removed = append(s.removed, name)
+	sort.Strings(s.removed)
+	return nil
+}
+
+func (s *fakeVariableStore) target() string {
+	return `owner "test"`
+}
+
+func testVariableSet(t *testing.T, store *fakeVariableStore) (*schema.Resource, *client.Client) {
+	c, err := client.New(client.Config{URL: "https://circleci.com/api/v2/", Organization: "org"})
+	assert.NoError(t, err)
+
+	set := variableSet{
+		resourceType: "circleci_test_environment_variables",
+		owner:        "owner",
+		newStore: func(c *client.Client, owner string) variableStore {
+			return store
+		},
+	}
+
+	return set.resource(&schema.Schema{Type: schema.TypeString, Required: true, ForceNew: true}), c
+}
+
+func testVariableSetState(variables map[string]string) *terraform.InstanceState {
+	state := &terraform.InstanceState{
+		ID: "test",
+		Attributes: map[string]string{
+			"id":                "test",
+			"organization":      "org",
+			"vcs_type":          "gh",
+			"owner":             "test",
+			"ignore_patterns.#": "0",
+		},
+	}
+
+	state.Attributes["variables.%"] = fmt.Sprint(len(variables))
+	for name, value := range variables {
+		state.Attributes["variables."+name] = value
+	}
+
+	return state
+}
+
+func TestVariableSetCreateDeletesExistingVariables(t *testing.T) {
+	store := newFakeVariableStore("EXISTING", "IGNORED_TOKEN")
+	r, c := testVariableSet(t, store)
+
+	diff, err := r.Diff(nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"owner":           "test",
+		"variables":       map[string]interface{}{"DECLARED": "value"},
+		"ignore_patterns": []interface{}{"IGNORED_*"},
+	}), c)
+	assert.NoError(t, err)
+
+	created, err := r.Apply(nil, diff, c)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"DECLARED": "value"}, store.stored)
+	assert.Equal(t, []string{"EXISTING"}, store.removed)
+	assert.Equal(t, "test", created.ID)
+	assert.Equal(t, "1", created.Attributes["variables.%"])
+	assert.Equal(t, hashString("value"), created.Attributes["variables.DECLARED"])
+}
+
+func TestVariableSetDeletesUndeclaredVariables(t *testing.T) {
+	store := newFakeVariableStore("KEPT", "CHANGED", "UNDECLARED", "IGNORED_TOKEN")
+	r, c := testVariableSet(t, store)
+
+	state := testVariableSetState(map[string]string{
+		"KEPT":    hashString("unchanged"),
+		"CHANGED": hashString("before"),
+		"MISSING": hashString("deleted outside of Terraform"),
+	})
+	state.Attributes["ignore_patterns.#"] = "1"
+	state.Attributes["ignore_patterns.0"] = "IGNORED_*"
+
+	// Undeclared variables are recorded as drift, with their listed value, and missing ones are dropped
+	state, err := r.Refresh(state, c)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", state.Attributes["variables.%"])
+	assert.Equal(t, hashString("unchanged"), state.Attributes["variables.KEPT"])
+	assert.Equal(t, "xxxxUNDECLARED", state.Attributes["variables.UNDECLARED"])
+
+	diff, err := r.Diff(state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"owner": "test",
+		"variables": map[string]interface{}{
+			"KEPT":    "unchanged",
+			"CHANGED": "after",
+			"MISSING": "deleted outside of Terraform",
+		},
+		"ignore_patterns": []interface{}{"IGNORED_*"},
+	}), c)
+	assert.NoError(t, err)
+
+	updated, err := r.Apply(state, diff, c)
+	assert.NoError(t, err)
+
+	// Unchanged variables are not written again, and ignored ones are left alone
+	assert.Equal(t, map[string]string{"CHANGED": "after", "MISSING": "deleted outside of Terraform"}, store.stored)
+	assert.Equal(t, []string{"UNDECLARED"}, store.removed)
+	assert.Equal(t, "3", updated.Attributes["variables.%"])
+	assert.Equal(t, hashString("unchanged"), updated.Attributes["variables.KEPT"])
+	assert.Equal(t, hashString("after"), updated.Attributes["variables.CHANGED"])
+	assert.Equal(t, hashString("deleted outside of Terraform"), updated.Attributes["variables.MISSING"])
+}
+
+func TestVariableSetUpdateKeepsVariablesIgnoredByNewPatterns(t *testing.T) {
+	store := newFakeVariableStore("DECLARED", "UNDECLARED_TOKEN")
+	r, c := testVariableSet(t, store)
+
+	state := testVariableSetState(map[string]string{
+		"DECLARED":         hashString("value"),
+		"UNDECLARED_TOKEN": "xxxxUNDECLARED_TOKEN",
+	})
+
+	diff, err := r.Diff(state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"owner":           "test",
+		"variables":       map[string]interface{}{"DECLARED": "value"},
+		"ignore_patterns": []interface{}{"*_TOKEN"},
+	}), c)
+	assert.NoError(t, err)
+
+	updated, err := r.Apply(state, diff, c)
+	assert.NoError(t, err)
+
+	assert.Empty(t, store.stored)
+	assert.Empty(t, store.removed)
+	assert.Equal(t, "1", updated.Attributes["variables.%"])
+}
+
+func TestVariableSetUpdateFailure(t *testing.T) {
+	store := newFakeVariableStore("VAR")
+	store.failStores = true
+	r, c := testVariableSet(t, store)
+
+	state := testVariableSetState(map[string]string{"VAR": hashString("before")})
+
+	diff, err := r.Diff(state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"owner":     "test",
+		"variables": map[string]interface{}{"VAR": "after", "ADDED": "new"},
+	}), c)
+	assert.NoError(t, err)
+
+	// The state of a failed update is the previous one, rather than the planned one holding the new values
+	updated, err := r.Apply(state, diff, c)
+	assert.Error(t, err)
+	assert.Equal(t, "1", updated.Attributes["variables.%"])
+	assert.Equal(t, hashString("before"), updated.Attributes["variables.VAR"])
+}
+
+func TestVariableSetDelete(t *testing.T) {
+	store := newFakeVariableStore("DECLARED", "UNDECLARED", "IGNORED_TOKEN")
+	r, c := testVariableSet(t, store)
+
+	state := testVariableSetState(map[string]string{
+		"DECLARED":   hashString("value"),
+		"UNDECLARED": "xxxxUNDECLARED",
+	})
+
+	_, err := r.Apply(state, &terraform.InstanceDiff{Destroy: true}, c)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"DECLARED", "UNDECLARED"}, store.removed)
+	assert.Equal(t, map[string]string{"IGNORED_TOKEN": "xxxxIGNORED_TOKEN"}, store.variables)
+}
+
+func TestVariableSetImport(t *testing.T) {
+	store := newFakeVariableStore("FIRST", "SECOND")
+	r, c := testVariableSet(t, store)
+
+	// Every variable is imported as undeclared, with its listed value
+	imported, err := r.Importer.State(r.Data(&terraform.InstanceState{ID: "gh/other-org:test"}), c)
+	assert.NoError(t, err)
+	assert.Len(t, imported, 1)
+
+	d := imported[0]
+	assert.Equal(t, "test", d.Id())
+	assert.Equal(t, "test", d.Get("owner"))
+	assert.Equal(t, "other-org", d.Get("organization"))
+	assert.Equal(t, map[string]interface{}{"FIRST": "xxxxFIRST", "SECOND": "xxxxSECOND"}, d.Get("variables"))
+}
@@ -0,0 +1,225 @@
+package circleci
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"golang.org/x/sync/errgroup"
+)
+
+// variableParallelism bounds the number of variables an authoritative resource changes at the same time.
+// Requests remain subject to the provider's max_concurrent_requests and requests_per_second.
+const variableParallelism = 4
+
+// ignorePatternsSchema is the attribute listing the variables which an authoritative resource leaves alone,
+// e.g. because they are managed by other tooling
+func ignorePatternsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		Elem: &schema.Schema{
+			Type:         schema.TypeString,
+			ValidateFunc: validateGlobPatternFunc,
+		},
+		Description: "Glob patterns, e.g. `AWS_*`, of undeclared variables which are neither reported as drift nor deleted.",
+	}
+}
+
+// variablesSchema is the attribute declaring every variable owned by an authoritative resource. Only hashes
+// of the values are stored in the state.
+func variablesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:      schema.TypeMap,
+		Required:  true,
+		Sensitive: true,
+		Elem: &schema.Schema{
+			Type: schema.TypeString,
+		},
+		ValidateFunc:     validateEnvironmentVariableMapFunc,
+		DiffSuppressFunc: suppressHashedValueDiff,
+		Description:      "The environment variables, as a map of names to values. Any other variable is deleted, unless it matches `ignore_patterns`.",
+	}
+}
+
+// sensitiveSchema is the attribute choosing whether the value of a variable is a secret, in which case only its
+// hash is stored in the state
+func sensitiveSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     true,
+		Description: "Whether the value is a secret. Otherwise, the value is stored in the state as is and changes to it are shown in plans as `plain_value`.",
+	}
+}
+
+// plainValueSchema is the attribute showing the value of a variable which is not a secret
+func plainValueSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "The value of the environment variable, when `sensitive` is false.",
+	}
+}
+
+// upgradeSensitiveState marks variables created before they could be declared as not sensitive as secrets
+func upgradeSensitiveState(rawState map[string]interface{}, m interface{}) (map[string]interface{}, error) {
+	rawState["sensitive"] = true
+	rawState["plain_value"] = ""
+
+	return rawState, nil
+}
+
+// suppressSensitiveValueDiff ignores differences between the value of a secret variable in the configuration
+// and its hash stored in the state
+func suppressSensitiveValueDiff(k, old, new string, d *schema.ResourceData) bool {
+	return d.Get("sensitive").(bool) && old == hashString(new)
+}
+
+// valueChanged returns whether a plan changes the value of a variable. Unlike ResourceDiff.HasChange, which
+// compares the configuration with the state as is, it compares the value of a secret with its hash.
+func valueChanged(d *schema.ResourceDiff) bool {
+	if !d.NewValueKnown("value") {
+		return true
+	}
+
+	old, new := d.GetChange("value")
+	if wasSensitive, _ := d.GetChange("sensitive"); wasSensitive.(bool) {
+		return old.(string) != hashString(new.(string))
+	}
+
+	return old.(string) != new.(string)
+}
+
+// customizePlainValueDiff shows the changes of the value of a variable which is not a secret in plans
+func customizePlainValueDiff(d *schema.ResourceDiff) error {
+	if d.Get("sensitive").(bool) {
+		if d.Get("plain_value").(string) != "" {
+			return d.SetNew("plain_value", "")
+		}
+
+		return nil
+	}
+
+	if !d.NewValueKnown("value") {
+		return d.SetNewComputed("plain_value")
+	}
+
+	if value := d.Get("value").(string); d.Get("plain_value").(string) != value {
+		return d.SetNew("plain_value", value)
+	}
+
+	return nil
+}
+
+// setVariableValue stores the value of a variable in the state, hashed when it is a secret
+func setVariableValue(d *schema.ResourceData, value string) {
+	if d.Get("sensitive").(bool) {
+		_ = d.Set("value", hashString(value))
+		_ = d.Set("plain_value", "")
+		return
+	}
+
+	_ = d.Set("value", value)
+	_ = d.Set("plain_value", value)
+}
+
+// keepStateOnError is deferred by updates with their error, so that a failed update keeps the previous state,
+// where the values of secrets are hashed, rather than the planned one, where they are in clear
+func keepStateOnError(d *schema.ResourceData, err *error) {
+	if *err != nil {
+		d.Partial(true)
+	}
+}
+
+// suppressHashedValueDiff ignores differences between the values of a map in the configuration and
+// their hashes stored in the state
+func suppressHashedValueDiff(k, old, new string, d *schema.ResourceData) bool {
+	if strings.HasSuffix(k, ".%") {
+		return false
+	}
+
+	return old == hashString(new)
+}
+
+// matchesAnyPattern returns whether a variable name matches any of the given glob patterns
+func matchesAnyPattern(name string, patterns []interface{}) bool {
+	for _, pattern := range patterns {
+		// Patterns have been validated by the schema
+		if matched, _ := path.Match(pattern.(string), name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// variableChanges compares the variables stored in the state with those of the configuration. It returns
+// the variables to create or update, with their values, and the names of the variables to delete. Variables
+// whose hashed value did not change reach the apply with the value of the state, since their diff is
+// suppressed, and are left alone. An empty value in the state is unknown and always written. Variables
+// matching the ignored patterns are never deleted.
+func variableChanges(old, new map[string]interface{}, ignored []interface{}) (upserts map[string]string, deletes []string) {
+	upserts = map[string]string{}
+	for name, value := range new {
+		known, ok := old[name]
+		if ok && known.(string) != "" && (known.(string) == value.(string) || known.(string) == hashString(value.(string))) {
+			continue
+		}
+		upserts[name] = value.(string)
+	}
+
+	for name := range old {
+		if _, ok := new[name]; !ok && !matchesAnyPattern(name, ignored) {
+			deletes = append(deletes, name)
+		}
+	}
+
+	return upserts, deletes
+}
+
+// storedVariables returns the variables to store in the state once the upserts are applied: the hashes of
+// the written values, and the values already stored for the others
+func storedVariables(new map[string]interface{}, upserts map[string]string) map[string]string {
+	stored := make(map[string]string, len(new))
+	for name, value := range new {
+		if upsert, ok := upserts[name]; ok {
+			stored[name] = hashString(upsert)
+		} else {
+			stored[name] = value.(string)
+		}
+	}
+
+	return stored
+}
+
+// applyVariableChanges stores and deletes variables in parallel, stopping at the first failure. The target
+// names the owner of the variables in error messages, e.g. `context "my-context"`.
+func applyVariableChanges(ctx context.Context, target string, upserts map[string]string, deletes []string, store func(ctx context.Context, name, value string) error, remove func(ctx context.Context, name string) error) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(variableParallelism)
+
+	for name, value := range upserts {
+		name, value := name, value
+		g.Go(func() error {
+			if err := store(ctx, name, value); err != nil {
+				return fmt.Errorf("failed to store environment variable %s: %w", name, describeAPIError(err, target))
+			}
+			return nil
+		})
+	}
+
+	for _, name := range deletes {
+		name := name
+		g.Go(func() error {
+			if err := remove(ctx, name); err != nil {
+				return fmt.Errorf("failed to delete environment variable %s: %w", name, describeAPIError(err, target))
+			}
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
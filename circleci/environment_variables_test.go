@@ -0,0 +1,126 @@
+package circleci
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariableChanges(t *testing.T) {
+	old := map[string]interface{}{
+		"UNCHANGED": hashString("same"),
+		"CHANGED":   hashString("before"),
+		"REMOVED":   hashString("gone"),
+		"UNMANAGED": "",
+	}
+	new := map[string]interface{}{
+		"UNCHANGED": "same",
+		"CHANGED":   "after",
+		"ADDED":     "new",
+	}
+
+	upserts, deletes := variableChanges(old, new, nil)
+	sort.Strings(deletes)
+
+	assert.Equal(t, map[string]string{"CHANGED": "after", "ADDED": "new"}, upserts)
+	assert.Equal(t, []string{"REMOVED", "UNMANAGED"}, deletes)
+
+	// The diff of unchanged values is suppressed, so that the apply sees the value of the state
+	new["UNCHANGED"] = hashString("same")
+
+	upserts, deletes = variableChanges(old, new, []interface{}{"UN*"})
+
+	assert.Equal(t, map[string]string{"CHANGED": "after", "ADDED": "new"}, upserts)
+	assert.Equal(t, []string{"REMOVED"}, deletes)
+	assert.Equal(t, map[string]string{
+		"UNCHANGED": hashString("same"),
+		"CHANGED":   hashString("after"),
+		"ADDED":     hashString("new"),
+	}, storedVariables(new, upserts))
+}
+
+func TestApplyVariableChangesIsBounded(t *testing.T) {
+	var inFlight, peak int32
+	track := func() {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	}
+
+	upserts := map[string]string{}
+	for _, name := range []string{"A", "B", "C", "D", "E", "F"} {
+		upserts[name] = "value"
+	}
+
+	var mutex sync.Mutex
+	var stored, deleted []string
+
+	err := applyVariableChanges(context.Background(), `context "test"`, upserts, []string{"G", "H", "I"},
+		func(ctx context.Context, name, value string) error {
+			track()
+			mutex.Lock()
+			defer mutex.Unlock()
+			stored = append(stored, name)
+			return nil
+		},
+		func(ctx context.Context, name string) error {
+			track()
+			mutex.Lock()
+			defer mutex.Unlock()
+			deleted = append(deleted, name)
+			return nil
+		},
+	)
+
+	assert.NoError(t, err)
+	assert.Len(t, stored, 6)
+	assert.Len(t, deleted, 3)
+	assert.LessOrEqual(t, peak, int32(variableParallelism))
+}
+
+func TestApplyVariableChangesReportsFailures(t *testing.T) {
+	failure := errors.New("failure")
+
+	err := applyVariableChanges(context.Background(), `context "test"`, map[string]string{"A": "value"}, nil,
+		func(ctx context.Context, name, value string) error {
+			return failure
+		},
+		nil,
+	)
+
+	assert.ErrorIs(t, err, failure)
+	assert.Contains(t, err.Error(), "failed to store environment variable A")
+}
+
+func TestSuppressSensitiveValueDiff(t *testing.T) {
+	resource := resourceCircleCIEnvironmentVariable()
+
+	sensitive := schema.TestResourceDataRaw(t, resource.Schema, map[string]interface{}{"value": "secret"})
+	assert.True(t, suppressSensitiveValueDiff("value", hashString("secret"), "secret", sensitive))
+	assert.False(t, suppressSensitiveValueDiff("value", hashString("other"), "secret", sensitive))
+	assert.False(t, suppressSensitiveValueDiff("value", "secret", "secret", sensitive))
+
+	plain := schema.TestResourceDataRaw(t, resource.Schema, map[string]interface{}{"value": "eu-west-1", "sensitive": false})
+	assert.False(t, suppressSensitiveValueDiff("value", hashString("eu-west-1"), "eu-west-1", plain))
+
+	setVariableValue(sensitive, "secret")
+	assert.Equal(t, hashString("secret"), sensitive.Get("value"))
+	assert.Equal(t, "", sensitive.Get("plain_value"))
+
+	setVariableValue(plain, "eu-west-1")
+	assert.Equal(t, "eu-west-1", plain.Get("value"))
+	assert.Equal(t, "eu-west-1", plain.Get("plain_value"))
+}
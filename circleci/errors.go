@@ -0,0 +1,35 @@
+package circleci
+
+import (
+	"errors"
+	"fmt"
+
+	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+)
+
+// describeAPIError explains an API error in terms of what the user can do about it. The target
+// names the object the failed operation was acting on, e.g. `project "my-project"`.
+func describeAPIError(err error, target string) error {
+	switch {
+	case errors.Is(err, client.ErrReadOnly):
+		return fmt.Errorf("cannot modify %s, the provider is configured with read_only: %w", target, err)
+	case errors.Is(err, client.ErrUnsupported):
+		return fmt.Errorf("cannot manage %s, check the provider's server_version: %w", target, err)
+	case errors.Is(err, client.ErrUnauthorized):
+		return fmt.Errorf("the API token is invalid or expired, check the provider's api_token: %w", err)
+	case errors.Is(err, client.ErrForbidden):
+		return fmt.Errorf("the API token lacks permission on %s: %w", target, err)
+	case errors.Is(err, client.ErrNotFound):
+		return fmt.Errorf("%s does not exist or is not visible to the API token: %w", target, err)
+	case errors.Is(err, client.ErrConflict):
+		return fmt.Errorf("%s conflicts with the current state of the object, it may have been modified concurrently: %w", target, err)
+	case errors.Is(err, client.ErrRateLimited):
+		return fmt.Errorf("rate limited while accessing %s, consider lowering requests_per_second or max_concurrent_requests: %w", target, err)
+	case errors.Is(err, client.ErrBadRequest):
+		return fmt.Errorf("the request for %s was rejected as invalid: %w", target, err)
+	case errors.Is(err, client.ErrServer):
+		return fmt.Errorf("the CircleCI API failed while accessing %s, try again later: %w", target, err)
+	}
+
+	return err
+}
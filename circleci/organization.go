@@ -0,0 +1,109 @@
+package circleci
+
+import (
+	"strings"
+
+	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// organizationSchema is the attribute overriding the provider's organization for a resource. The effective
+// organization is stored in the state, so that later changes of the provider's default do not move the resource.
+func organizationSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Computed:    true,
+		ForceNew:    true,
+		Description: "The CircleCI organization of the resource. Defaults to the provider's organization.",
+	}
+}
+
+// vcsTypeSchema is the attribute overriding the provider's VCS type for a resource
+func vcsTypeSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:             schema.TypeString,
+		Optional:         true,
+		Computed:         true,
+		ForceNew:         true,
+		ValidateFunc:     validation.StringInSlice(client.VCSTypes(), true),
+		DiffSuppressFunc: suppressEquivalentVCSDiff,
+		Description:      "The VCS type of the resource's organization. Defaults to the provider's VCS type.",
+	}
+}
+
+// dataSourceOrganizationSchema is the attribute overriding the provider's organization for a data source
+func dataSourceOrganizationSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "The CircleCI organization to look up. Defaults to the provider's organization.",
+	}
+}
+
+// dataSourceVCSTypeSchema is the attribute overriding the provider's VCS type for a data source
+func dataSourceVCSTypeSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		ValidateFunc: validation.StringInSlice(client.VCSTypes(), true),
+		Description:  "The VCS type of the organization to look up. Defaults to the provider's VCS type.",
+	}
+}
+
+// resourceClient returns the client for the organization of a resource or data source, which defaults
+// to the provider's
+func resourceClient(d *schema.ResourceData, m interface{}) (*client.Client, error) {
+	c := m.(*client.Client)
+
+	vcs, _ := d.Get("vcs_type").(string)
+	organization, _ := d.Get("organization").(string)
+
+	return c.WithOrganization(vcs, organization)
+}
+
+// setOrganization stores the effective organization of a resource
+func setOrganization(d *schema.ResourceData, c *client.Client) {
+	_ = d.Set("organization", c.Organization())
+	_ = d.Set("vcs_type", c.VCS())
+}
+
+// importOrganization handles the optional `<vcs>/<organization>:` prefix of import IDs, e.g.
+// `gh/other-org:my-context`, which imports a resource of another organization than the provider's.
+// The organization is stored and the prefix removed from the ID, so that importers see the usual ID.
+func importOrganization(d *schema.ResourceData) {
+	prefix, id, ok := strings.Cut(d.Id(), ":")
+	if !ok || id == "" {
+		return
+	}
+
+	vcs, organization, ok := strings.Cut(prefix, "/")
+	if !ok || organization == "" || strings.Contains(organization, "/") {
+		return
+	}
+
+	if _, err := client.NormalizeVCS(vcs); err != nil {
+		return
+	}
+
+	_ = d.Set("vcs_type", vcs)
+	_ = d.Set("organization", organization)
+	d.SetId(id)
+}
+
+// suppressEquivalentVCSDiff ignores differences between the long and short forms of a VCS type, e.g. github and gh
+func suppressEquivalentVCSDiff(k, old, new string, d *schema.ResourceData) bool {
+	oldVCS, err := client.NormalizeVCS(old)
+	if err != nil {
+		return false
+	}
+
+	newVCS, err := client.NormalizeVCS(new)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(oldVCS, newVCS)
+}
@@ -0,0 +1,39 @@
+package circleci
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportOrganization(t *testing.T) {
+	tests := []struct {
+		id           string
+		expectedID   string
+		vcs          string
+		organization string
+	}{
+		{id: "my-context", expectedID: "my-context"},
+		{id: "gh/other-org:my-context", expectedID: "my-context", vcs: "gh", organization: "other-org"},
+		{id: "circleci/0b7d3e4f-8a5c-4b1d-9e2f-6c3a1d7e8f90:my-context", expectedID: "my-context", vcs: "circleci", organization: "0b7d3e4f-8a5c-4b1d-9e2f-6c3a1d7e8f90"},
+		{id: "bitbucket/other-org:gh/other-org/my-project/VAR", expectedID: "gh/other-org/my-project/VAR", vcs: "bitbucket", organization: "other-org"},
+		// Checkout key fingerprints contain colons
+		{id: "my-project/12:34:56:78:90:12:34:56:78:90:12:34:56:78:90:12", expectedID: "my-project/12:34:56:78:90:12:34:56:78:90:12:34:56:78:90:12"},
+		{id: "gh/org/my-project/12:34:56:78:90:12:34:56:78:90:12:34:56:78:90:12", expectedID: "gh/org/my-project/12:34:56:78:90:12:34:56:78:90:12:34:56:78:90:12"},
+		{id: "gh/other-org:", expectedID: "gh/other-org:"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceCircleCIContext().Schema, map[string]interface{}{})
+			d.SetId(test.id)
+
+			importOrganization(d)
+
+			assert.Equal(t, test.expectedID, d.Id())
+			assert.Equal(t, test.vcs, d.Get("vcs_type"))
+			assert.Equal(t, test.organization, d.Get("organization"))
+		})
+	}
+}
@@ -1,14 +1,24 @@
 package circleci
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
 	"github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
 )
 
+// credentialsValidationTimeout bounds the validation of the credentials when configuring the provider
+const credentialsValidationTimeout = 1 * time.Minute
+
 func Provider() terraform.ResourceProvider {
-	return &schema.Provider{
+	p := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"organization": {
 				Type:        schema.TypeString,
@@ -17,16 +27,33 @@ func Provider() terraform.ResourceProvider {
 				Description: "The CircleCI organization.",
 			},
 			"api_token": {
-				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("CIRCLECI_TOKEN", nil),
-				Description: "The token key for API operations.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("CIRCLECI_TOKEN", nil),
+				ConflictsWith: []string{"api_token_file", "api_token_command"},
+				Description:   "The token key for API operations.",
+			},
+			"api_token_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("CIRCLECI_TOKEN_FILE", nil),
+				ConflictsWith: []string{"api_token", "api_token_command"},
+				Description:   "The path to a file containing the token for API operations. The file is read again when the API rejects the token.",
+			},
+			"api_token_command": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				MinItems:      1,
+				ConflictsWith: []string{"api_token", "api_token_file"},
+				Description:   "A command, and its arguments, printing the token for API operations on its standard output. The command is run again when the API rejects the token.",
 			},
 			"vcs_type": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				DefaultFunc: schema.EnvDefaultFunc("CIRCLECI_VCS_TYPE", "github"),
-				Description: "The VCS type for the organization.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CIRCLECI_VCS_TYPE", "github"),
+				ValidateFunc: validation.StringInSlice(client.VCSTypes(), true),
+				Description:  "The VCS type for the organization: `github` (`gh`), `bitbucket` (`bb`), or `gitlab` (`circleci`) for GitLab and GitHub App organizations.",
 			},
 			"url": {
 				Type:        schema.TypeString,
@@ -34,26 +61,250 @@ func Provider() terraform.ResourceProvider {
 				DefaultFunc: schema.EnvDefaultFunc("CIRCLECI_URL", "https://circleci.com/api/v2/"),
 				Description: "The URL of the Circle CI API (v2)",
 			},
+			"api_v1_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CIRCLECI_API_V1_URL", ""),
+				Description: "The URL of the Circle CI API (v1.1), used for features older server versions only serve on API v1.1. Defaults to `/api/v1.1/` on the host of `url`.",
+			},
+			"server_version": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CIRCLECI_SERVER_VERSION", ""),
+				ValidateFunc: validateServerVersionFunc,
+				Description:  "The version of a self-hosted CircleCI server installation, e.g. `3.4`. Features which the version does not support fail with a clear error. Leave empty for CircleCI cloud.",
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CIRCLECI_MAX_RETRIES", rest.DefaultRetryPolicy.MaxRetries),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The maximum number of retries for rate limited, failed or timed out requests. Set to 0 to disable retries.",
+			},
+			"min_retry_backoff": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CIRCLECI_MIN_RETRY_BACKOFF", rest.DefaultRetryPolicy.MinBackoff.String()),
+				ValidateFunc: validateDurationFunc,
+				Description:  "The delay before the first retry, doubled for each subsequent retry.",
+			},
+			"max_retry_backoff": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CIRCLECI_MAX_RETRY_BACKOFF", rest.DefaultRetryPolicy.MaxBackoff.String()),
+				ValidateFunc: validateDurationFunc,
+				Description:  "The maximum delay between two retries, including delays requested by the API.",
+			},
+			"retry_non_idempotent": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CIRCLECI_RETRY_NON_IDEMPOTENT", false),
+				Description: "Whether to retry non-idempotent (POST) requests after server or network errors. Rate limited requests are always retried.",
+			},
+			"max_concurrent_requests": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CIRCLECI_MAX_CONCURRENT_REQUESTS", 0),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The maximum number of API requests in flight at any time, across all resources. Set to 0 for no limit.",
+			},
+			"requests_per_second": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CIRCLECI_REQUESTS_PER_SECOND", 0.0),
+				ValidateFunc: validation.FloatAtLeast(0),
+				Description:  "The maximum number of API requests sent per second, across all resources. Set to 0 for no limit.",
+			},
+			"proxy_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CIRCLECI_PROXY_URL", ""),
+				Description: "The URL of the proxy API requests go through. Defaults to the proxy set by the `HTTPS_PROXY`, `HTTP_PROXY` and `NO_PROXY` environment variables.",
+			},
+			"ca_cert_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("CIRCLECI_CA_CERT_FILE", ""),
+				ConflictsWith: []string{"ca_cert_pem"},
+				Description:   "The path to a PEM-encoded bundle of certificate authorities trusted in addition to the system ones, e.g. for a CircleCI server behind an internal CA.",
+			},
+			"ca_cert_pem": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"ca_cert_file"},
+				Description:   "A PEM-encoded bundle of certificate authorities trusted in addition to the system ones.",
+			},
+			"client_cert": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"client_key"},
+				Description:  "The PEM-encoded client certificate presented to the API, for servers requiring mutual TLS.",
+			},
+			"client_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"client_cert"},
+				Description:  "The PEM-encoded private key of the client certificate.",
+			},
+			"insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CIRCLECI_INSECURE_SKIP_VERIFY", false),
+				Description: "Whether to skip the verification of the API server certificate. This should only be used for testing.",
+			},
+			"read_only": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CIRCLECI_READ_ONLY", false),
+				Description: "Whether to refuse any API request which would modify CircleCI, e.g. for plans in pull request pipelines.",
+			},
+			"skip_credentials_validation": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CIRCLECI_SKIP_CREDENTIALS_VALIDATION", false),
+				Description: "Whether to skip checking that the API token is valid and grants access to the organization when configuring the provider, e.g. for offline plans.",
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"circleci_environment_variable":         resourceCircleCIEnvironmentVariable(),
-			"circleci_context":                      resourceCircleCIContext(),
-			"circleci_context_environment_variable": resourceCircleCIContextEnvironmentVariable(),
-			"circleci_checkout_key":                 resourceCircleCICheckoutKey(),
+			"circleci_environment_variable":          resourceCircleCIEnvironmentVariable(),
+			"circleci_context":                       resourceCircleCIContext(),
+			"circleci_context_environment_variable":  resourceCircleCIContextEnvironmentVariable(),
+			"circleci_context_environment_variables": resourceCircleCIContextEnvironmentVariables(),
+			"circleci_checkout_key":                  resourceCircleCICheckoutKey(),
+			"circleci_project_environment_variables": resourceCircleCIProjectEnvironmentVariables(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
 			"circleci_project": dataSourceCircleCIProject(),
 			"circleci_context": dataSourceCircleCIContext(),
 		},
-		ConfigureFunc: providerConfigure,
 	}
+
+	p.ConfigureFunc = func(d *schema.ResourceData) (interface{}, error) {
+		return providerConfigure(d, withParentTrace(p.StopContext()))
+	}
+
+	return p
 }
 
-func providerConfigure(d *schema.ResourceData) (interface{}, error) {
-	return client.New(client.Config{
+func providerConfigure(d *schema.ResourceData, stopContext context.Context) (interface{}, error) {
+	// Durations have been validated by the schema
+	minBackoff, _ := time.ParseDuration(d.Get("min_retry_backoff").(string))
+	maxBackoff, _ := time.ParseDuration(d.Get("max_retry_backoff").(string))
+
+	if minBackoff > maxBackoff {
+		return nil, fmt.Errorf("min_retry_backoff (%s) cannot be greater than max_retry_backoff (%s)", minBackoff, maxBackoff)
+	}
+
+	tokens, err := tokenSource(d)
+	if err != nil {
+		return nil, err
+	}
+
+	caCertPEM := []byte(d.Get("ca_cert_pem").(string))
+	if path := d.Get("ca_cert_file").(string); path != "" {
+		if caCertPEM, err = ioutil.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("could not read ca_cert_file: %w", err)
+		}
+	}
+
+	c, err := client.New(client.Config{
 		URL:          d.Get("url").(string),
-		Token:        d.Get("api_token").(string),
+		Token:        tokens,
 		Organization: d.Get("organization").(string),
 		VCS:          d.Get("vcs_type").(string),
+
+		APIv1URL:      d.Get("api_v1_url").(string),
+		ServerVersion: d.Get("server_version").(string),
+
+		Transport: rest.TransportConfig{
+			ProxyURL:           d.Get("proxy_url").(string),
+			CACertPEM:          caCertPEM,
+			ClientCertPEM:      []byte(d.Get("client_cert").(string)),
+			ClientKeyPEM:       []byte(d.Get("client_key").(string)),
+			InsecureSkipVerify: d.Get("insecure_skip_verify").(bool),
+		},
+
+		Retry: rest.RetryPolicy{
+			MaxRetries:         d.Get("max_retries").(int),
+			MinBackoff:         minBackoff,
+			MaxBackoff:         maxBackoff,
+			RetryNonIdempotent: d.Get("retry_non_idempotent").(bool),
+		},
+
+		MaxConcurrentRequests: d.Get("max_concurrent_requests").(int),
+		RequestsPerSecond:     d.Get("requests_per_second").(float64),
+
+		ReadOnly: d.Get("read_only").(bool),
+
+		StopContext: stopContext,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !d.Get("skip_credentials_validation").(bool) {
+		ctx, cancel := context.WithTimeout(stopContext, credentialsValidationTimeout)
+		defer cancel()
+
+		if err := c.ValidateCredentials(ctx); err != nil {
+			return nil, fmt.Errorf("could not validate the provider credentials, set skip_credentials_validation to skip this check: %w",
+				describeAPIError(err, "the current user"))
+		}
+	}
+
+	return c, nil
+}
+
+// tokenSource returns where the API token comes from. An explicit token file or command takes
+// precedence over the token, which may be set in the environment. When none is configured, the token
+// of the circleci CLI is used.
+func tokenSource(d *schema.ResourceData) (rest.TokenSource, error) {
+	if path := d.Get("api_token_file").(string); path != "" {
+		return client.FileTokenSource(path), nil
+	}
+
+	if args := d.Get("api_token_command").([]interface{}); len(args) > 0 {
+		command := make([]string, len(args))
+		for i, arg := range args {
+			command[i], _ = arg.(string)
+		}
+
+		return client.CommandTokenSource(command), nil
+	}
+
+	if token := d.Get("api_token").(string); token != "" {
+		return rest.StaticToken(token), nil
+	}
+
+	path, err := client.CLIConfigPath()
+	if err == nil {
+		var token string
+		if token, err = client.CLIConfigToken(path); err == nil {
+			return rest.StaticToken(token), nil
+		}
+	}
+
+	return nil, fmt.Errorf("no API token configured: set api_token, api_token_file or api_token_command, or run `circleci setup` (%v)", err)
+}
+
+// operationImport is the operation of importers, which are bounded by the read timeout
+const operationImport = "import"
+
+// operationContext returns the context for an operation of a resource or data source, e.g. create or read.
+// It is cancelled when the operation exceeds the timeout configured for it, or when Terraform stops the provider.
+// The operation is traced as a single span, parent of the API calls it makes, which ends with the returned function.
+func operationContext(c *client.Client, d *schema.ResourceData, resource string, operation string) (context.Context, context.CancelFunc) {
+	timeout := operation
+	if operation == operationImport {
+		timeout = schema.TimeoutRead
+	}
+
+	ctx, span := startOperationSpan(c.StopContext(), resource, operation, d.Id())
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(timeout))
+
+	return ctx, func() {
+		cancel()
+		span.End()
+	}
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
 )
+
 var (
 	testAccProvider  *schema.Provider
 	testAccProviders map[string]terraform.ResourceProvider
@@ -1,7 +1,9 @@
 package circleci
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
 
@@ -17,9 +19,17 @@ func resourceCircleCICheckoutKey() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			State: resourceCircleCICheckoutKeyImport,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
+			"organization": organizationSchema(),
+			"vcs_type":     vcsTypeSchema(),
 			"project": {
-				Description: "The name of the CircleCI project to create the checkout key in.",
+				Description: "The name of the CircleCI project to create the checkout key in, or its slug, e.g. `gh/org/repo` or `circleci/<org-id>/<project-id>`.",
 				Type:        schema.TypeString,
 				Required:    true,
 				ForceNew:    true,
@@ -58,14 +68,20 @@ func resourceCircleCICheckoutKey() *schema.Resource {
 }
 
 func resourceCircleCICheckoutKeyCreate(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_checkout_key", schema.TimeoutCreate)
+	defer cancel()
 
 	project := d.Get("project").(string)
 	keyType := d.Get("type").(string)
 
-	checkoutKey, err := c.CreateCheckoutKey(project, keyType)
+	checkoutKey, err := c.CreateCheckoutKey(ctx, project, keyType)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to create project checkout key: %w", describeAPIError(err, fmt.Sprintf("project %q", project)))
 	}
 
 	id, _ := c.ComposeElementId([]string{project, checkoutKey.Fingerprint})
@@ -80,14 +96,27 @@ func resourceCircleCICheckoutKeyCreate(d *schema.ResourceData, m interface{}) er
 }
 
 func resourceCircleCICheckoutKeyRead(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_checkout_key", schema.TimeoutRead)
+	defer cancel()
+
+	setOrganization(d, c)
 
 	project := d.Get("project").(string)
 	fingerprint := d.Get("fingerprint").(string)
 
-	checkoutKey, err := c.GetCheckoutKey(project, fingerprint)
+	checkoutKey, err := c.GetCheckoutKey(ctx, project, fingerprint)
 	if err != nil {
-		return fmt.Errorf("failed to get project checkout key: %w", err)
+		if errors.Is(err, client.ErrNotFound) {
+			d.SetId("")
+			return nil
+		}
+
+		return fmt.Errorf("failed to get project checkout key: %w", describeAPIError(err, fmt.Sprintf("project %q", project)))
 	}
 
 	d.Set("fingerprint", checkoutKey.Fingerprint)
@@ -99,13 +128,19 @@ func resourceCircleCICheckoutKeyRead(d *schema.ResourceData, m interface{}) erro
 }
 
 func resourceCircleCICheckoutKeyDelete(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_checkout_key", schema.TimeoutDelete)
+	defer cancel()
 
 	project := d.Get("project").(string)
 	fingerprint := d.Get("fingerprint").(string)
 
-	if err := c.DeleteCheckoutKey(project, fingerprint); err != nil {
-		return fmt.Errorf("failed to delete project checkout key: %w", err)
+	if err := c.DeleteCheckoutKey(ctx, project, fingerprint); err != nil {
+		return fmt.Errorf("failed to delete project checkout key: %w", describeAPIError(err, fmt.Sprintf("project %q", project)))
 	}
 
 	d.SetId("")
@@ -114,7 +149,17 @@ func resourceCircleCICheckoutKeyDelete(d *schema.ResourceData, m interface{}) er
 }
 
 func resourceCircleCICheckoutKeyImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-	c := m.(*client.Client)
+	importOrganization(d)
+
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_checkout_key", operationImport)
+	defer cancel()
+
+	setOrganization(d, c)
 
 	parts, err := c.DecomposeElementId(d.Id(), []string{"project", "fingerprint"})
 	if err != nil {
@@ -124,9 +169,9 @@ func resourceCircleCICheckoutKeyImport(d *schema.ResourceData, m interface{}) ([
 	project := parts["project"]
 	fingerprint := parts["fingerprint"]
 
-	checkoutKey, err := c.GetCheckoutKey(project, fingerprint)
+	checkoutKey, err := c.GetCheckoutKey(ctx, project, fingerprint)
 	if err != nil {
-		return nil, fmt.Errorf("checkout key not exist: %w", err)
+		return nil, fmt.Errorf("checkout key not exist: %w", describeAPIError(err, fmt.Sprintf("checkout key %q of project %q", fingerprint, project)))
 	}
 
 	_ = d.Set("project", project)
@@ -1,6 +1,7 @@
 package circleci
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -137,7 +138,7 @@ func testAccCircleCICheckoutKeyCheckDestroy(s *terraform.State) error {
 			continue
 		}
 
-		has, err := c.HasProjectCheckoutKey(rs.Primary.Attributes["project"], rs.Primary.Attributes["fingerprint"])
+		has, err := c.HasProjectCheckoutKey(context.Background(), rs.Primary.Attributes["project"], rs.Primary.Attributes["fingerprint"])
 		if err != nil {
 			return err
 		}
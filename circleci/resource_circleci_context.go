@@ -1,91 +1,241 @@
 package circleci
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 )
 
 func resourceCircleCIContext() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceCircleCIContextCreate,
 		Read:   resourceCircleCIContextRead,
+		Update: resourceCircleCIContextUpdate,
 		Delete: resourceCircleCIContextDelete,
 		Importer: &schema.ResourceImporter{
 			State: resourceCircleCIContextImport,
 		},
 
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
+			"organization": organizationSchema(),
+			"vcs_type":     vcsTypeSchema(),
 			"name": {
 				Type:        schema.TypeString,
 				Required:    true,
 				ForceNew:    true,
 				Description: "The name of the context",
 			},
+			"owner_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"owner_slug"},
+				Description:   "The ID of the organization or account owning the context. Required for GitLab and GitHub App organizations, unless their `organization` is set.",
+			},
+			"owner_slug": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"owner_id"},
+				Description:   "The slug of the organization or account owning the context, e.g. `gh/my-org`. Defaults to the slug of the organization.",
+			},
+			"owner_type": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ForceNew:         true,
+				ValidateFunc:     validation.StringInSlice([]string{client.ContextOwnerOrganization, client.ContextOwnerAccount}, false),
+				DiffSuppressFunc: suppressDefaultOwnerTypeDiff,
+				Description:      "The type of the owner of the context, either `organization` or `account`. Defaults to `organization`.",
+			},
+			"force_destroy": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to delete the context even though it still contains environment variables. Otherwise, deleting such a context fails.",
+			},
 		},
 	}
 }
 
 func resourceCircleCIContextCreate(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_context", schema.TimeoutCreate)
+	defer cancel()
 
 	name := d.Get("name").(string)
 
-	ctx, err := c.CreateContext(name)
+	owner, err := resourceCircleCIContextOwner(ctx, c, d)
+	if err != nil {
+		return err
+	}
+
+	circleContext, err := c.CreateOwnedContext(ctx, owner, name)
 	if err != nil {
-		return fmt.Errorf("error creating context: %w", err)
+		return fmt.Errorf("error creating context: %w", describeAPIError(err, fmt.Sprintf("context %q", name)))
 	}
 
-	d.SetId(ctx.ID)
+	d.SetId(circleContext.ID)
+	setContextOwner(d, owner)
 
 	return resourceCircleCIContextRead(d, m)
 }
 
 func resourceCircleCIContextRead(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_context", schema.TimeoutRead)
+	defer cancel()
+
+	setOrganization(d, c)
 
 	id := d.Id()
 
-	ctx, err := c.GetContext(id)
+	circleContext, err := c.GetContext(ctx, id)
 	if err != nil {
 		if errors.Is(err, client.ErrContextNotFound) {
 			d.SetId("")
 			return nil
 		}
 
-		return err
+		return describeAPIError(err, fmt.Sprintf("context %s", id))
 	}
 
-	_ = d.Set("name", ctx.Name)
+	_ = d.Set("name", circleContext.Name)
 
 	return nil
 }
 
+// resourceCircleCIContextUpdate only records changes of force_destroy, every other attribute forces a new context
+func resourceCircleCIContextUpdate(d *schema.ResourceData, m interface{}) error {
+	return resourceCircleCIContextRead(d, m)
+}
+
 func resourceCircleCIContextDelete(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_context", schema.TimeoutDelete)
+	defer cancel()
+
+	// Variables which depend on the context are deleted before it, but others may remain, e.g. ones managed
+	// in another state or created outside of Terraform. The listing may be stale, so it is fetched again.
+	if !d.Get("force_destroy").(bool) {
+		variables, err := c.FetchContextEnvironmentVariablesByID(ctx, d.Id())
+		if err != nil {
+			if errors.Is(err, client.ErrNotFound) {
+				return nil
+			}
 
-	if err := c.DeleteContext(d.Id()); err != nil {
-		return fmt.Errorf("error deleting context: %w", err)
+			return fmt.Errorf("error deleting context: %w", describeAPIError(err, fmt.Sprintf("context %s", d.Id())))
+		}
+
+		if len(*variables) > 0 {
+			names := make([]string, 0, len(*variables))
+			for _, variable := range *variables {
+				names = append(names, variable.Variable)
+			}
+			sort.Strings(names)
+
+			return fmt.Errorf("context %q still contains environment variables: %s, delete them or set force_destroy to delete the context anyway", d.Get("name").(string), strings.Join(names, ", "))
+		}
+	}
+
+	if err := c.DeleteContext(ctx, d.Id()); err != nil {
+		return fmt.Errorf("error deleting context: %w", describeAPIError(err, fmt.Sprintf("context %s", d.Id())))
 	}
 
 	return nil
 }
 
 func resourceCircleCIContextImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-	c := m.(*client.Client)
+	importOrganization(d)
+
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_context", operationImport)
+	defer cancel()
+
+	setOrganization(d, c)
 
 	context_name := d.Id()
 
-	ctx, err := c.GetContextByName(context_name)
+	owner, err := c.DefaultContextOwner(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not import context: %w", describeAPIError(err, fmt.Sprintf("context %q", context_name)))
+	}
+
+	circleContext, err := c.GetOwnedContextByName(ctx, owner, context_name)
 	if err != nil {
-		return nil, fmt.Errorf("context does not exist: %w", err)
+		return nil, fmt.Errorf("could not import context: %w", describeAPIError(err, fmt.Sprintf("context %q", context_name)))
 	}
 
-	d.SetId(ctx.ID)
-	_ = d.Set("name", ctx.Name)
+	d.SetId(circleContext.ID)
+	_ = d.Set("name", circleContext.Name)
+	_ = d.Set("force_destroy", false)
+	setContextOwner(d, owner)
 
 	return []*schema.ResourceData{d}, nil
 }
+
+// resourceCircleCIContextOwner returns the owner of a context, which defaults to the organization
+func resourceCircleCIContextOwner(ctx context.Context, c *client.Client, d *schema.ResourceData) (*client.ContextOwner, error) {
+	ownerID := d.Get("owner_id").(string)
+	ownerSlug := d.Get("owner_slug").(string)
+	ownerType := d.Get("owner_type").(string)
+
+	if ownerID == "" && ownerSlug == "" {
+		if ownerType == client.ContextOwnerAccount {
+			return nil, errors.New("owner_id or owner_slug is required for contexts owned by an account")
+		}
+
+		return c.DefaultContextOwner(ctx)
+	}
+
+	if ownerType == "" {
+		ownerType = client.ContextOwnerOrganization
+	}
+
+	return &client.ContextOwner{ID: ownerID, Slug: ownerSlug, Type: ownerType}, nil
+}
+
+func setContextOwner(d *schema.ResourceData, owner *client.ContextOwner) {
+	_ = d.Set("owner_id", owner.ID)
+	_ = d.Set("owner_slug", owner.Slug)
+	_ = d.Set("owner_type", owner.Type)
+}
+
+// suppressDefaultOwnerTypeDiff ignores an explicit organization owner type for contexts whose owner is
+// not known, e.g. created before owners were recorded, since contexts are owned by organizations by default
+func suppressDefaultOwnerTypeDiff(k, old, new string, d *schema.ResourceData) bool {
+	return old == "" && new == client.ContextOwnerOrganization
+}
@@ -1,30 +1,70 @@
 package circleci
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
 
+	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 )
 
 func resourceCircleCIContextEnvironmentVariable() *schema.Resource {
 	return &schema.Resource{
 		// Create and Update have the same implementation, since the upstream API uses PUT
 		Create: resourceCircleCIContextEnvironmentVariableCreate,
-		Update: resourceCircleCIContextEnvironmentVariableCreate,
+		Update: resourceCircleCIContextEnvironmentVariableUpdate,
 		Read:   resourceCircleCIContextEnvironmentVariableRead,
 		Delete: resourceCircleCIContextEnvironmentVariableDelete,
 		Importer: &schema.ResourceImporter{
 			State: resourceCircleCIContextEnvironmentVariableImport,
 		},
+		CustomizeDiff: resourceCircleCIContextEnvironmentVariableCustomizeDiff,
+
+		// Version 0 identified the context by its name only, version 1 only stored hashes of the values
+		SchemaVersion: 2,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceCircleCIContextEnvironmentVariableV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceCircleCIContextEnvironmentVariableStateUpgradeV0,
+			},
+			{
+				Version: 1,
+				Type:    resourceCircleCIContextEnvironmentVariableV1().CoreConfigSchema().ImpliedType(),
+				Upgrade: upgradeSensitiveState,
+			},
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 
 		Schema: map[string]*schema.Schema{
+			"organization": organizationSchema(),
+			"vcs_type":     vcsTypeSchema(),
 			"context": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The name of the context where the environment variable is defined",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"context", "context_id"},
+				Description:  "The name of the context where the environment variable is defined. Conflicts with `context_id`.",
+			},
+			"context_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"context", "context_id"},
+				ValidateFunc: validation.IsUUID,
+				Description:  "The ID of the context where the environment variable is defined. Conflicts with `context`.",
 			},
 			"name": {
 				Type:         schema.TypeString,
@@ -34,84 +74,309 @@ func resourceCircleCIContextEnvironmentVariable() *schema.Resource {
 				ValidateFunc: validateEnvironmentVariableNameFunc,
 			},
 			"value": {
-				Type:      schema.TypeString,
-				Required:  true,
-				Sensitive: true,
-				StateFunc: func(value interface{}) string {
-					return hashString(value.(string))
-				},
-				Description: "The value that will be set for the environment variable.",
+				Type:             schema.TypeString,
+				Required:         true,
+				Sensitive:        true,
+				DiffSuppressFunc: suppressSensitiveValueDiff,
+				Description:      "The value that will be set for the environment variable.",
+			},
+			"sensitive":   sensitiveSchema(),
+			"plain_value": plainValueSchema(),
+			"created_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "When the environment variable was created",
+			},
+			"updated_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "When the provider last wrote the environment variable. A later update made outside of Terraform is detected and reverted.",
 			},
 		},
 	}
 }
 
+func resourceCircleCIContextEnvironmentVariableCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	if valueChanged(d) {
+		if err := d.SetNewComputed("updated_at"); err != nil {
+			return err
+		}
+	}
+
+	return customizePlainValueDiff(d)
+}
+
 func resourceCircleCIContextEnvironmentVariableCreate(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	return resourceCircleCIContextEnvironmentVariableStore(d, m, schema.TimeoutCreate)
+}
+
+func resourceCircleCIContextEnvironmentVariableUpdate(d *schema.ResourceData, m interface{}) (err error) {
+	defer keepStateOnError(d, &err)
+
+	return resourceCircleCIContextEnvironmentVariableStore(d, m, schema.TimeoutUpdate)
+}
+
+func resourceCircleCIContextEnvironmentVariableStore(d *schema.ResourceData, m interface{}, timeout string) error {
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_context_environment_variable", timeout)
+	defer cancel()
+
+	contextID, err := resourceCircleCIContextEnvironmentVariableContextID(ctx, c, d)
+	if err != nil {
+		return fmt.Errorf("error storing environment variable: %w", err)
+	}
 
-	context := d.Get("context").(string)
 	name := d.Get("name").(string)
 	value := d.Get("value").(string)
 
-	if err := c.CreateOrUpdateContextEnvironmentVariable(context, name, value); err != nil {
-		return fmt.Errorf("error storing environment variable: %w", err)
+	if err := c.CreateOrUpdateContextEnvironmentVariableByID(ctx, contextID, name, value); err != nil {
+		return fmt.Errorf("error storing environment variable: %w", describeAPIError(err, contextTarget(d)))
 	}
 
-	id, _ := c.ComposeElementId([]string{context, name})
+	id, _ := c.ComposeElementId([]string{contextID, name})
 
 	d.SetId(id)
+	_ = d.Set("context_id", contextID)
+	setVariableValue(d, value)
+
+	// Record the time of this write, rather than comparing it with the previous one
+	_ = d.Set("updated_at", "")
 
 	return resourceCircleCIContextEnvironmentVariableRead(d, m)
 }
 
 func resourceCircleCIContextEnvironmentVariableRead(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
 
-	context := d.Get("context").(string)
-	name := d.Get("name").(string)
+	ctx, cancel := operationContext(c, d, "circleci_context_environment_variable", schema.TimeoutRead)
+	defer cancel()
 
-	has, err := c.HasContextEnvironmentVariable(context, name)
+	setOrganization(d, c)
+
+	contextID, err := resourceCircleCIContextEnvironmentVariableContextID(ctx, c, d)
 	if err != nil {
+		if errors.Is(err, client.ErrNotFound) {
+			d.SetId("")
+			return nil
+		}
+
 		return fmt.Errorf("failed to get context environment variables: %w", err)
 	}
 
-	if !has {
-		d.SetId("")
+	name := d.Get("name").(string)
+
+	variable, err := c.GetContextEnvironmentVariableByID(ctx, contextID, name)
+	if err != nil {
+		if errors.Is(err, client.ErrNotFound) {
+			d.SetId("")
+			return nil
+		}
+
+		return fmt.Errorf("failed to get context environment variables: %w", describeAPIError(err, contextTarget(d)))
+	}
+
+	// The variable was written again since the provider last did, e.g. in the CircleCI UI. Forgetting
+	// the hash of the value makes the next apply write it again.
+	writtenAt := variable.LastWrittenAt()
+	if recorded, err := time.Parse(time.RFC3339Nano, d.Get("updated_at").(string)); err == nil && writtenAt.After(recorded) {
+		_ = d.Set("value", "")
+	}
+
+	// States upgraded without looking up the context still use its name in their ID
+	if id, _ := c.ComposeElementId([]string{contextID, name}); d.Id() != id {
+		d.SetId(id)
 	}
 
+	_ = d.Set("context_id", contextID)
+	_ = d.Set("created_at", variable.CreatedAt.Format(time.RFC3339Nano))
+	_ = d.Set("updated_at", writtenAt.Format(time.RFC3339Nano))
+
 	return nil
 }
 
 func resourceCircleCIContextEnvironmentVariableDelete(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
 
-	context := d.Get("context").(string)
-	name := d.Get("name").(string)
+	ctx, cancel := operationContext(c, d, "circleci_context_environment_variable", schema.TimeoutDelete)
+	defer cancel()
 
-	if err := c.DeleteContextEnvironmentVariable(context, name); err != nil {
+	contextID, err := resourceCircleCIContextEnvironmentVariableContextID(ctx, c, d)
+	if err != nil {
 		return fmt.Errorf("error deleting environment variable: %w", err)
 	}
 
+	name := d.Get("name").(string)
+
+	if err := c.DeleteContextEnvironmentVariableByID(ctx, contextID, name); err != nil {
+		return fmt.Errorf("error deleting environment variable: %w", describeAPIError(err, contextTarget(d)))
+	}
+
 	return nil
 }
 
 func resourceCircleCIContextEnvironmentVariableImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-	c := m.(*client.Client)
+	importOrganization(d)
+
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_context_environment_variable", operationImport)
+	defer cancel()
+
+	setOrganization(d, c)
 
 	parts, err := c.DecomposeElementId(d.Id(), []string{"context", "name"})
 	if err != nil {
 		return nil, err
 	}
 
-	context := parts["context"]
+	// The context is either its ID or its name
+	if _, err := uuid.Parse(parts["context"]); err == nil {
+		_ = d.Set("context_id", parts["context"])
+	} else {
+		_ = d.Set("context", parts["context"])
+	}
+
+	contextID, err := resourceCircleCIContextEnvironmentVariableContextID(ctx, c, d)
+	if err != nil {
+		return nil, fmt.Errorf("could not import environment variable: %w", err)
+	}
+
 	name := parts["name"]
 
-	if has, err := c.HasContextEnvironmentVariable(context, name); !has || err != nil {
-		return nil, fmt.Errorf("environment variable does not exist: %v", err)
+	if _, err := c.GetContextEnvironmentVariableByID(ctx, contextID, name); err != nil {
+		if errors.Is(err, client.ErrNotFound) {
+			return nil, fmt.Errorf("environment variable %s does not exist in %s", name, contextTarget(d))
+		}
+
+		return nil, fmt.Errorf("could not import environment variable: %w", describeAPIError(err, contextTarget(d)))
 	}
 
-	_ = d.Set("context", parts["context"])
-	_ = d.Set("name", parts["name"])
+	id, _ := c.ComposeElementId([]string{contextID, name})
+
+	d.SetId(id)
+	_ = d.Set("context_id", contextID)
+	_ = d.Set("name", name)
+	_ = d.Set("sensitive", true)
 
 	return []*schema.ResourceData{d}, nil
 }
+
+// resourceCircleCIContextEnvironmentVariableContextID returns the ID of the context of a variable, looking
+// it up by name when only the name is known
+func resourceCircleCIContextEnvironmentVariableContextID(ctx context.Context, c *client.Client, d *schema.ResourceData) (string, error) {
+	if id := d.Get("context_id").(string); id != "" {
+		return id, nil
+	}
+
+	circleContext, err := c.GetContextByName(ctx, d.Get("context").(string))
+	if err != nil {
+		return "", describeAPIError(err, contextTarget(d))
+	}
+
+	return circleContext.ID, nil
+}
+
+// contextTarget names the context of a variable in error messages
+func contextTarget(d *schema.ResourceData) string {
+	if name := d.Get("context").(string); name != "" {
+		return fmt.Sprintf("context %q", name)
+	}
+
+	return fmt.Sprintf("context %q", d.Get("context_id").(string))
+}
+
+func resourceCircleCIContextEnvironmentVariableV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"organization": organizationSchema(),
+			"vcs_type":     vcsTypeSchema(),
+			"context": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"value": {
+				Type:      schema.TypeString,
+				Required:  true,
+				Sensitive: true,
+			},
+			"created_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"updated_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceCircleCIContextEnvironmentVariableV1() *schema.Resource {
+	resource := resourceCircleCIContextEnvironmentVariableV0()
+	resource.Schema["context"].Required = false
+	resource.Schema["context"].Optional = true
+	resource.Schema["context_id"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Optional: true,
+		Computed: true,
+		ForceNew: true,
+	}
+
+	return resource
+}
+
+// resourceCircleCIContextEnvironmentVariableStateUpgradeV0 looks up the ID of the context of a variable,
+// which was only identified by its name, and makes it part of the resource ID. A variable whose context
+// no longer exists is left as is, and dropped by the next refresh. So is the state when the provider is not
+// configured, e.g. by commands which do not call the API, in which case the next refresh rewrites the ID.
+func resourceCircleCIContextEnvironmentVariableStateUpgradeV0(rawState map[string]interface{}, m interface{}) (map[string]interface{}, error) {
+	if m == nil {
+		return rawState, nil
+	}
+
+	vcs, _ := rawState["vcs_type"].(string)
+	organization, _ := rawState["organization"].(string)
+	contextName, _ := rawState["context"].(string)
+	name, _ := rawState["name"].(string)
+
+	c, err := m.(*client.Client).WithOrganization(vcs, organization)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(c.StopContext(), 5*time.Minute)
+	defer cancel()
+
+	circleContext, err := c.GetContextByName(ctx, contextName)
+	if err != nil {
+		if errors.Is(err, client.ErrNotFound) {
+			return rawState, nil
+		}
+
+		return nil, fmt.Errorf("could not upgrade the state of environment variable %s: %w", name, describeAPIError(err, fmt.Sprintf("context %q", contextName)))
+	}
+
+	rawState["context_id"] = circleContext.ID
+	rawState["id"], _ = c.ComposeElementId([]string{circleContext.ID, name})
+
+	return rawState, nil
+}
@@ -0,0 +1,113 @@
+package circleci
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceCircleCIContextEnvironmentVariableStateUpgradeV0(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("owner-slug") {
+		case "gh/org":
+			_, _ = w.Write([]byte(`{"items": [{"id": "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c", "name": "production"}], "next_page_token": null}`))
+		default:
+			_, _ = w.Write([]byte(`{"items": [], "next_page_token": null}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := client.New(client.Config{URL: server.URL + "/api/v2/", Organization: "org"})
+	assert.NoError(t, err)
+
+	upgraded, err := resourceCircleCIContextEnvironmentVariableStateUpgradeV0(map[string]interface{}{
+		"id":           "production/VAR",
+		"organization": "org",
+		"vcs_type":     "gh",
+		"context":      "production",
+		"name":         "VAR",
+	}, c)
+	assert.NoError(t, err)
+	assert.Equal(t, "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c/VAR", upgraded["id"])
+	assert.Equal(t, "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c", upgraded["context_id"])
+	assert.Equal(t, "production", upgraded["context"])
+
+	// The context of the variable was deleted
+	upgraded, err = resourceCircleCIContextEnvironmentVariableStateUpgradeV0(map[string]interface{}{
+		"id":           "production/VAR",
+		"organization": "other",
+		"vcs_type":     "gh",
+		"context":      "production",
+		"name":         "VAR",
+	}, c)
+	assert.NoError(t, err)
+	assert.Equal(t, "production/VAR", upgraded["id"])
+	assert.Nil(t, upgraded["context_id"])
+
+	// The provider is not configured
+	upgraded, err = resourceCircleCIContextEnvironmentVariableStateUpgradeV0(map[string]interface{}{
+		"id":           "production/VAR",
+		"organization": "org",
+		"vcs_type":     "gh",
+		"context":      "production",
+		"name":         "VAR",
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "production/VAR", upgraded["id"])
+	assert.Nil(t, upgraded["context_id"])
+}
+
+func TestUpgradeSensitiveState(t *testing.T) {
+	upgraded, err := upgradeSensitiveState(map[string]interface{}{
+		"id":    "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c/VAR",
+		"value": hashString("secret"),
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, true, upgraded["sensitive"])
+	assert.Equal(t, "", upgraded["plain_value"])
+	assert.Equal(t, hashString("secret"), upgraded["value"])
+}
+
+func TestResourceCircleCIContextEnvironmentVariableReadUpgradedWithoutMeta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/environment-variable") {
+			_, _ = w.Write([]byte(`{"items": [{"variable": "VAR", "context_id": "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c", "created_at": "2024-01-02T03:04:05Z", "updated_at": "2024-01-02T03:04:05Z"}], "next_page_token": null}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"items": [{"id": "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c", "name": "production"}], "next_page_token": null}`))
+	}))
+	defer server.Close()
+
+	c, err := client.New(client.Config{URL: server.URL + "/api/v2/", Organization: "org"})
+	assert.NoError(t, err)
+
+	upgraded, err := resourceCircleCIContextEnvironmentVariableStateUpgradeV0(map[string]interface{}{
+		"id":           "production/VAR",
+		"organization": "org",
+		"vcs_type":     "gh",
+		"context":      "production",
+		"name":         "VAR",
+		"value":        hashString("secret"),
+	}, nil)
+	assert.NoError(t, err)
+	upgraded, err = upgradeSensitiveState(upgraded, nil)
+	assert.NoError(t, err)
+
+	attributes := map[string]string{}
+	for key, value := range upgraded {
+		attributes[key] = fmt.Sprint(value)
+	}
+
+	// The first refresh completes the migration of the ID
+	d := resourceCircleCIContextEnvironmentVariable().Data(&terraform.InstanceState{ID: attributes["id"], Attributes: attributes})
+	assert.NoError(t, resourceCircleCIContextEnvironmentVariableRead(d, c))
+	assert.Equal(t, "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c/VAR", d.Id())
+	assert.Equal(t, "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c", d.Get("context_id"))
+}
@@ -1,19 +1,20 @@
 package circleci
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
 
 	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
 
-	"github.com/CircleCI-Public/circleci-cli/api"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAccCircleCIContextEnvironmentVariable_basic(t *testing.T) {
-	variable := &api.EnvironmentVariable{}
+	variable := &client.EnvironmentVariable{}
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -28,6 +29,8 @@ func TestAccCircleCIContextEnvironmentVariable_basic(t *testing.T) {
 					resource.TestCheckResourceAttr("circleci_context_environment_variable.foo", "name", "VAR"),
 					resource.TestCheckResourceAttr("circleci_context_environment_variable.foo", "value", hashString("secret-value")),
 					resource.TestCheckResourceAttrSet("circleci_context_environment_variable.foo", "context"),
+					resource.TestCheckResourceAttrSet("circleci_context_environment_variable.foo", "created_at"),
+					resource.TestCheckResourceAttrSet("circleci_context_environment_variable.foo", "updated_at"),
 				),
 			},
 		},
@@ -35,7 +38,7 @@ func TestAccCircleCIContextEnvironmentVariable_basic(t *testing.T) {
 }
 
 func TestAccCircleCIContextEnvironmentVariable_update(t *testing.T) {
-	variable := &api.EnvironmentVariable{}
+	variable := &client.EnvironmentVariable{}
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -67,7 +70,7 @@ func TestAccCircleCIContextEnvironmentVariable_update(t *testing.T) {
 }
 
 func TestAccCircleCIContextEnvironmentVariable_import(t *testing.T) {
-	context := &api.Context{}
+	circleContext := &client.Context{}
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -76,12 +79,12 @@ func TestAccCircleCIContextEnvironmentVariable_import(t *testing.T) {
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCircleCIContextEnvironmentVariable_basic,
-				Check:  testAccCheckCircleCIContextExists("circleci_context.foo", context),
+				Check:  testAccCheckCircleCIContextExists("circleci_context.foo", circleContext),
 			},
 			{
 				ResourceName: "circleci_context_environment_variable.foo",
 				ImportStateIdFunc: func(s *terraform.State) (string, error) {
-					return fmt.Sprintf("%s/%s", context.ID, "VAR"), nil
+					return fmt.Sprintf("%s/%s", circleContext.ID, "VAR"), nil
 				},
 				PreConfig: func() {
 					os.Setenv("CIRCLECI_ENV_VALUE", "secret-value")
@@ -129,7 +132,50 @@ func TestAccCircleCIContextEnvironmentVariable_import_name(t *testing.T) {
 	})
 }
 
-func testAccCheckCircleCIContextEnvironmentVariableExists(addr string, variable *api.EnvironmentVariable) resource.TestCheckFunc {
+func TestAccCircleCIContextEnvironmentVariable_notSensitive(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCircleCIContextEnvironmentVariableDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCircleCIContextEnvironmentVariable_notSensitive,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("circleci_context_environment_variable.foo", "sensitive", "false"),
+					resource.TestCheckResourceAttr("circleci_context_environment_variable.foo", "value", "production"),
+					resource.TestCheckResourceAttr("circleci_context_environment_variable.foo", "plain_value", "production"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCircleCIContextEnvironmentVariable_import_id(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCircleCIContextEnvironmentVariableDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCircleCIContextEnvironmentVariable_id,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("circleci_context_environment_variable.foo", "context_id", "circleci_context.foo", "id"),
+				),
+			},
+			{
+				ResourceName: "circleci_context_environment_variable.foo",
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return fmt.Sprintf("%s/%s", s.RootModule().Resources["circleci_context.foo"].Primary.ID, "VAR"), nil
+				},
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"value"},
+			},
+		},
+	})
+}
+
+func testAccCheckCircleCIContextEnvironmentVariableExists(addr string, variable *client.EnvironmentVariable) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		c := testAccProvider.Meta().(*client.Client)
 
@@ -141,7 +187,7 @@ func testAccCheckCircleCIContextEnvironmentVariableExists(addr string, variable
 			return fmt.Errorf("No instance ID is set")
 		}
 
-		envs, err := c.ListContextEnvironmentVariables(resource.Primary.Attributes["context"])
+		envs, err := c.ListContextEnvironmentVariablesByID(context.Background(), resource.Primary.Attributes["context_id"])
 		if err != nil {
 			return fmt.Errorf("error getting context: %w", err)
 		}
@@ -173,16 +219,16 @@ func testAccCheckCircleCIContextEnvironmentVariableDestroy(s *terraform.State) e
 			return fmt.Errorf("No instance ID is set")
 		}
 
-		_, err := c.GetContext(resource.Primary.Attributes["context"])
+		_, err := c.GetContext(context.Background(), resource.Primary.Attributes["context_id"])
 		if err == nil {
-			return fmt.Errorf("Context still exists: %s", resource.Primary.Attributes["context"])
+			return fmt.Errorf("Context still exists: %s", resource.Primary.Attributes["context_id"])
 		}
 	}
 
 	return nil
 }
 
-func testAccCheckCircleCIContextEnvironmentVariableAttributes_basic(variable *api.EnvironmentVariable) resource.TestCheckFunc {
+func testAccCheckCircleCIContextEnvironmentVariableAttributes_basic(variable *client.EnvironmentVariable) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		if variable.Variable != "VAR" {
 			return fmt.Errorf("Unexpected variable: %s", variable.Variable)
@@ -192,7 +238,7 @@ func testAccCheckCircleCIContextEnvironmentVariableAttributes_basic(variable *ap
 	}
 }
 
-func testAccCheckCircleCIContextEnvironmentVariableAttributes_update(variable *api.EnvironmentVariable) resource.TestCheckFunc {
+func testAccCheckCircleCIContextEnvironmentVariableAttributes_update(variable *client.EnvironmentVariable) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		if variable.Variable != "VAR_UPDATED" {
 			return fmt.Errorf("Unexpected variable: %s", variable.Variable)
@@ -208,9 +254,9 @@ resource "circleci_context" "foo" {
 }
 
 resource "circleci_context_environment_variable" "foo" {
-	variable   = "VAR"
-	value      = "secret-value"
-	context = circleci_context.foo.id
+	name    = "VAR"
+	value   = "secret-value"
+	context = circleci_context.foo.name
 }
 `
 
@@ -220,8 +266,69 @@ resource "circleci_context" "foo" {
 }
 
 resource "circleci_context_environment_variable" "foo" {
-	variable   = "VAR_UPDATED"
-	value      = "secret-value-updated"
-	context = circleci_context.foo.id
+	name    = "VAR_UPDATED"
+	value   = "secret-value-updated"
+	context = circleci_context.foo.name
+}
+`
+
+const testAccCircleCIContextEnvironmentVariable_id = `
+resource "circleci_context" "foo" {
+	name = "terraform-test"
+}
+
+resource "circleci_context_environment_variable" "foo" {
+	name       = "VAR"
+	value      = "secret-value"
+	context_id = circleci_context.foo.id
+}
+`
+
+const testAccCircleCIContextEnvironmentVariable_notSensitive = `
+resource "circleci_context" "foo" {
+	name = "terraform-test"
+}
+
+resource "circleci_context_environment_variable" "foo" {
+	name      = "NODE_ENV"
+	value     = "production"
+	sensitive = false
+	context   = circleci_context.foo.name
 }
 `
+
+func TestResourceCircleCIContextEnvironmentVariableDiff(t *testing.T) {
+	r := resourceCircleCIContextEnvironmentVariable()
+	state := &terraform.InstanceState{
+		ID: "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c/VAR",
+		Attributes: map[string]string{
+			"id":           "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c/VAR",
+			"organization": "org",
+			"vcs_type":     "gh",
+			"context":      "production",
+			"context_id":   "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c",
+			"name":         "VAR",
+			"value":        hashString("secret"),
+			"sensitive":    "true",
+			"plain_value":  "",
+			"created_at":   "2024-01-02T03:04:05Z",
+			"updated_at":   "2024-01-02T03:04:05Z",
+		},
+	}
+	config := map[string]interface{}{
+		"context": "production",
+		"name":    "VAR",
+		"value":   "secret",
+	}
+
+	// The value of the state is the hash of the configured one
+	diff, err := r.Diff(state, terraform.NewResourceConfigRaw(config), nil)
+	assert.NoError(t, err)
+	assert.True(t, diff.Empty())
+
+	config["value"] = "changed"
+
+	diff, err = r.Diff(state, terraform.NewResourceConfigRaw(config), nil)
+	assert.NoError(t, err)
+	assert.True(t, diff.Attributes["updated_at"].NewComputed)
+}
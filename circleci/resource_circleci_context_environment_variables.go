@@ -0,0 +1,63 @@
+package circleci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func resourceCircleCIContextEnvironmentVariables() *schema.Resource {
+	set := variableSet{
+		resourceType: "circleci_context_environment_variables",
+		owner:        "context",
+		newStore: func(c *client.Client, circleContext string) variableStore {
+			return contextVariableStore{c: c, circleContext: circleContext}
+		},
+	}
+
+	return set.resource(&schema.Schema{
+		Type:        schema.TypeString,
+		Required:    true,
+		ForceNew:    true,
+		Description: "The name of the context owning the variables",
+	})
+}
+
+// contextVariableStore accesses the environment variables of a context, whose values are never returned
+type contextVariableStore struct {
+	c             *client.Client
+	circleContext string
+}
+
+func (s contextVariableStore) list(ctx context.Context) (map[string]string, error) {
+	variables, err := s.c.ListContextEnvironmentVariables(ctx, s.circleContext)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(*variables))
+	for _, variable := range *variables {
+		values[variable.Variable] = ""
+	}
+
+	return values, nil
+}
+
+func (s contextVariableStore) store(ctx context.Context, name, value string) error {
+	return s.c.CreateOrUpdateContextEnvironmentVariable(ctx, s.circleContext, name, value)
+}
+
+func (s contextVariableStore) remove(ctx context.Context, name string) error {
+	if err := s.c.DeleteContextEnvironmentVariable(ctx, s.circleContext, name); err != nil && !errors.Is(err, client.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (s contextVariableStore) target() string {
+	return fmt.Sprintf("context %q", s.circleContext)
+}
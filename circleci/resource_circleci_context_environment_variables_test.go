@@ -0,0 +1,157 @@
+package circleci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccCircleCIContextEnvironmentVariables_update(t *testing.T) {
+	contextName := "terraform-test-" + acctest.RandString(8)
+	resourceName := "circleci_context_environment_variables.foo"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCircleCIContextDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCircleCIContextEnvironmentVariablesConfig(contextName, `
+    FIRST  = "first-value"
+    SECOND = "second-value"
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "id", contextName),
+					resource.TestCheckResourceAttr(resourceName, "variables.%", "2"),
+					resource.TestCheckResourceAttr(resourceName, "variables.FIRST", hashString("first-value")),
+					resource.TestCheckResourceAttr(resourceName, "variables.SECOND", hashString("second-value")),
+					testAccCheckCircleCIContextEnvironmentVariableNames(contextName, "FIRST", "SECOND"),
+				),
+			},
+			{
+				Config: testAccCircleCIContextEnvironmentVariablesConfig(contextName, `
+    FIRST = "first-value-updated"
+    THIRD = "third-value"
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "variables.%", "2"),
+					resource.TestCheckResourceAttr(resourceName, "variables.FIRST", hashString("first-value-updated")),
+					resource.TestCheckResourceAttr(resourceName, "variables.THIRD", hashString("third-value")),
+					testAccCheckCircleCIContextEnvironmentVariableNames(contextName, "FIRST", "THIRD"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckCircleCIContextEnvironmentVariableNames(contextName string, names ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		c := testAccProvider.Meta().(*client.Client)
+
+		envs, err := c.ListContextEnvironmentVariables(context.Background(), contextName)
+		if err != nil {
+			return fmt.Errorf("error getting context: %w", err)
+		}
+
+		if len(*envs) != len(names) {
+			return fmt.Errorf("expected %d variables in context '%s', got %d", len(names), contextName, len(*envs))
+		}
+
+		for _, name := range names {
+			found := false
+			for _, v := range *envs {
+				found = found || v.Variable == name
+			}
+
+			if !found {
+				return fmt.Errorf("variable '%s' not found in context '%s'", name, contextName)
+			}
+		}
+
+		return nil
+	}
+}
+
+func testAccCircleCIContextEnvironmentVariablesConfig(contextName, variables string) string {
+	return fmt.Sprintf(`
+resource "circleci_context" "foo" {
+	name = "%s"
+}
+
+resource "circleci_context_environment_variables" "foo" {
+	context = circleci_context.foo.name
+
+	variables = {%s}
+}
+`, contextName, variables)
+}
+
+func TestContextVariableStore(t *testing.T) {
+	var mutex sync.Mutex
+	var requests []string
+	stored := map[string]string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch {
+		case r.Method == "PUT":
+			var variable struct {
+				Value string `json:"value"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&variable)
+			stored[path.Base(r.URL.Path)] = variable.Value
+			_, _ = w.Write([]byte(`{"variable": "` + path.Base(r.URL.Path) + `", "context_id": "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c"}`))
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Environment variable not found."}`))
+		case strings.HasSuffix(r.URL.Path, "/environment-variable"):
+			_, _ = w.Write([]byte(`{"items": [{"variable": "FIRST"}, {"variable": "SECOND"}], "next_page_token": null}`))
+		default:
+			_, _ = w.Write([]byte(`{"items": [{"id": "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c", "name": "production"}], "next_page_token": null}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := client.New(client.Config{URL: server.URL + "/api/v2/", Organization: "org"})
+	assert.NoError(t, err)
+
+	store := contextVariableStore{c: c, circleContext: "production"}
+	ctx := context.Background()
+
+	// The API never returns values, so variables are listed without one
+	variables, err := store.list(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"FIRST": "", "SECOND": ""}, variables)
+
+	assert.NoError(t, store.store(ctx, "FIRST", "first"))
+	assert.Equal(t, map[string]string{"FIRST": "first"}, stored)
+
+	// Variables which are already gone are not reported
+	assert.NoError(t, store.remove(ctx, "GONE"))
+
+	// The context is looked up by its name once, then its variables are addressed by its ID
+	assert.Equal(t, []string{
+		"GET /api/v2/context",
+		"GET /api/v2/context/4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c/environment-variable",
+		"PUT /api/v2/context/4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c/environment-variable/FIRST",
+		"DELETE /api/v2/context/4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c/environment-variable/GONE",
+	}, requests)
+	assert.Equal(t, `context "production"`, store.target())
+}
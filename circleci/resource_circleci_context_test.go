@@ -1,18 +1,23 @@
 package circleci
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
 	"testing"
 
 	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
 
-	"github.com/CircleCI-Public/circleci-cli/api"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAccCircleCIContext_basic(t *testing.T) {
-	context := &api.Context{}
+	circleContext := &client.Context{}
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -22,8 +27,8 @@ func TestAccCircleCIContext_basic(t *testing.T) {
 			{
 				Config: testAccCircleCIContext_basic,
 				Check: resource.ComposeTestCheckFunc(
-					testAccCheckCircleCIContextExists("circleci_context.foo", context),
-					testAccCheckCircleCIContextAttributes_basic(context),
+					testAccCheckCircleCIContextExists("circleci_context.foo", circleContext),
+					testAccCheckCircleCIContextAttributes_basic(circleContext),
 					resource.TestCheckResourceAttr("circleci_context.foo", "name", "terraform-test"),
 				),
 			},
@@ -32,7 +37,7 @@ func TestAccCircleCIContext_basic(t *testing.T) {
 }
 
 func TestAccCircleCIContext_update(t *testing.T) {
-	context := &api.Context{}
+	circleContext := &client.Context{}
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -42,16 +47,16 @@ func TestAccCircleCIContext_update(t *testing.T) {
 			{
 				Config: testAccCircleCIContext_basic,
 				Check: resource.ComposeTestCheckFunc(
-					testAccCheckCircleCIContextExists("circleci_context.foo", context),
-					testAccCheckCircleCIContextAttributes_basic(context),
+					testAccCheckCircleCIContextExists("circleci_context.foo", circleContext),
+					testAccCheckCircleCIContextAttributes_basic(circleContext),
 					resource.TestCheckResourceAttr("circleci_context.foo", "name", "terraform-test"),
 				),
 			},
 			{
 				Config: testAccCircleCIContext_update,
 				Check: resource.ComposeTestCheckFunc(
-					testAccCheckCircleCIContextExists("circleci_context.foo", context),
-					testAccCheckCircleCIContextAttributes_update(context),
+					testAccCheckCircleCIContextExists("circleci_context.foo", circleContext),
+					testAccCheckCircleCIContextAttributes_update(circleContext),
 					resource.TestCheckResourceAttr("circleci_context.foo", "name", "terraform-test-updated"),
 				),
 			},
@@ -60,7 +65,7 @@ func TestAccCircleCIContext_update(t *testing.T) {
 }
 
 func TestAccCircleCIContext_import(t *testing.T) {
-	context := &api.Context{}
+	circleContext := &client.Context{}
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -69,12 +74,12 @@ func TestAccCircleCIContext_import(t *testing.T) {
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCircleCIContext_basic,
-				Check:  testAccCheckCircleCIContextExists("circleci_context.foo", context),
+				Check:  testAccCheckCircleCIContextExists("circleci_context.foo", circleContext),
 			},
 			{
 				ResourceName: "circleci_context.foo",
 				ImportStateIdFunc: func(s *terraform.State) (string, error) {
-					return context.ID, nil
+					return circleContext.ID, nil
 				},
 				ImportState:       true,
 				ImportStateVerify: true,
@@ -84,7 +89,7 @@ func TestAccCircleCIContext_import(t *testing.T) {
 }
 
 func TestAccCircleCIContext_import_name(t *testing.T) {
-	context := &api.Context{}
+	circleContext := &client.Context{}
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -93,7 +98,7 @@ func TestAccCircleCIContext_import_name(t *testing.T) {
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCircleCIContext_basic,
-				Check:  testAccCheckCircleCIContextExists("circleci_context.foo", context),
+				Check:  testAccCheckCircleCIContextExists("circleci_context.foo", circleContext),
 			},
 			{
 				ResourceName:      "circleci_context.foo",
@@ -105,7 +110,38 @@ func TestAccCircleCIContext_import_name(t *testing.T) {
 	})
 }
 
-func testAccCheckCircleCIContextExists(addr string, context *api.Context) resource.TestCheckFunc {
+func TestAccCircleCIContext_forceDestroy(t *testing.T) {
+	circleContext := &client.Context{}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCircleCIContextDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCircleCIContext_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCircleCIContextExists("circleci_context.foo", circleContext),
+					func(s *terraform.State) error {
+						c := testAccProvider.Meta().(*client.Client)
+						return c.CreateOrUpdateContextEnvironmentVariableByID(context.Background(), circleContext.ID, "UNMANAGED", "value")
+					},
+				),
+			},
+			{
+				Config:      testAccCircleCIContext_basic,
+				Destroy:     true,
+				ExpectError: regexp.MustCompile("still contains environment variables: UNMANAGED"),
+			},
+			{
+				Config: testAccCircleCIContext_forceDestroy,
+				Check:  resource.TestCheckResourceAttr("circleci_context.foo", "force_destroy", "true"),
+			},
+		},
+	})
+}
+
+func testAccCheckCircleCIContextExists(addr string, circleContext *client.Context) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		c := testAccProvider.Meta().(*client.Client)
 
@@ -118,12 +154,12 @@ func testAccCheckCircleCIContextExists(addr string, context *api.Context) resour
 			return fmt.Errorf("No instance ID is set")
 		}
 
-		ctx, err := c.GetContext(resource.Primary.ID)
+		ctx, err := c.GetContext(context.Background(), resource.Primary.ID)
 		if err != nil {
 			return fmt.Errorf("error getting context: %w", err)
 		}
 
-		*context = *ctx
+		*circleContext = *ctx
 
 		return nil
 	}
@@ -141,7 +177,7 @@ func testAccCheckCircleCIContextDestroy(s *terraform.State) error {
 			return fmt.Errorf("No instance ID is set")
 		}
 
-		_, err := c.GetContext(resource.Primary.ID)
+		_, err := c.GetContext(context.Background(), resource.Primary.ID)
 		if err == nil {
 			return fmt.Errorf("Context %s still exists: %w", resource.Primary.ID, err)
 		}
@@ -150,20 +186,20 @@ func testAccCheckCircleCIContextDestroy(s *terraform.State) error {
 	return nil
 }
 
-func testAccCheckCircleCIContextAttributes_basic(context *api.Context) resource.TestCheckFunc {
+func testAccCheckCircleCIContextAttributes_basic(circleContext *client.Context) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
-		if context.Name != "terraform-test" {
-			return fmt.Errorf("Unexpected context name: %s", context.Name)
+		if circleContext.Name != "terraform-test" {
+			return fmt.Errorf("Unexpected context name: %s", circleContext.Name)
 		}
 
 		return nil
 	}
 }
 
-func testAccCheckCircleCIContextAttributes_update(context *api.Context) resource.TestCheckFunc {
+func testAccCheckCircleCIContextAttributes_update(circleContext *client.Context) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
-		if context.Name != "terraform-test-updated" {
-			return fmt.Errorf("Unexpected context name: %s", context.Name)
+		if circleContext.Name != "terraform-test-updated" {
+			return fmt.Errorf("Unexpected context name: %s", circleContext.Name)
 		}
 
 		return nil
@@ -181,3 +217,87 @@ resource "circleci_context" "foo" {
 	name = "terraform-test-updated"
 }
 `
+
+const testAccCircleCIContext_forceDestroy = `
+resource "circleci_context" "foo" {
+	name          = "terraform-test"
+	force_destroy = true
+}
+`
+
+func TestResourceCircleCIContextImportOrganization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("owner-slug") {
+		case "gh/other-org":
+			_, _ = w.Write([]byte(`{"items": [{"id": "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c", "name": "production"}], "next_page_token": null}`))
+		default:
+			_, _ = w.Write([]byte(`{"items": [], "next_page_token": null}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := client.New(client.Config{URL: server.URL + "/api/v2/", Organization: "org"})
+	assert.NoError(t, err)
+
+	d := resourceCircleCIContext().Data(&terraform.InstanceState{ID: "gh/other-org:production"})
+
+	imported, err := resourceCircleCIContextImport(d, c)
+	assert.NoError(t, err)
+	assert.Len(t, imported, 1)
+	assert.Equal(t, "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c", d.Id())
+	assert.Equal(t, "production", d.Get("name"))
+	assert.Equal(t, "other-org", d.Get("organization"))
+	assert.Equal(t, "gh", d.Get("vcs_type"))
+
+	// Without a prefix, the context is looked up in the provider's organization
+	d = resourceCircleCIContext().Data(&terraform.InstanceState{ID: "production"})
+
+	_, err = resourceCircleCIContextImport(d, c)
+	assert.Error(t, err)
+}
+
+func TestResourceCircleCIContextDelete(t *testing.T) {
+	var deleted bool
+	variables := `{"items": [], "next_page_token": null}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/context/4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c/environment-variable":
+			_, _ = w.Write([]byte(variables))
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v2/context/4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c":
+			deleted = true
+			_, _ = w.Write([]byte(`{"message": "Context deleted."}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not found."}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := client.New(client.Config{URL: server.URL + "/api/v2/", Organization: "org"})
+	assert.NoError(t, err)
+
+	data := func(forceDestroy string) *schema.ResourceData {
+		return resourceCircleCIContext().Data(&terraform.InstanceState{
+			ID: "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c",
+			Attributes: map[string]string{
+				"name":          "production",
+				"organization":  "org",
+				"vcs_type":      "gh",
+				"force_destroy": forceDestroy,
+			},
+		})
+	}
+
+	// A listing cached before the variables were created does not let the deletion through
+	_, err = c.ListContextEnvironmentVariablesByID(context.Background(), "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c")
+	assert.NoError(t, err)
+	variables = `{"items": [{"variable": "REMAINING"}, {"variable": "ADDED"}], "next_page_token": null}`
+
+	err = resourceCircleCIContextDelete(data("false"), c)
+	assert.EqualError(t, err, `context "production" still contains environment variables: ADDED, REMAINING, delete them or set force_destroy to delete the context anyway`)
+	assert.False(t, deleted)
+
+	assert.NoError(t, resourceCircleCIContextDelete(data("true"), c))
+	assert.True(t, deleted)
+}
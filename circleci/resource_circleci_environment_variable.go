@@ -3,6 +3,7 @@ package circleci
 import (
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"time"
 
@@ -15,19 +16,35 @@ func resourceCircleCIEnvironmentVariable() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceCircleCIEnvironmentVariableCreate,
 		Read:   resourceCircleCIEnvironmentVariableRead,
+		Update: resourceCircleCIEnvironmentVariableUpdate,
 		Delete: resourceCircleCIEnvironmentVariableDelete,
 		Importer: &schema.ResourceImporter{
 			State: resourceCircleCIEnvironmentVariableImport,
 		},
+		CustomizeDiff: resourceCircleCIEnvironmentVariableCustomizeDiff,
+
+		// Version 0 only stored hashes of the values
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceCircleCIEnvironmentVariableV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: upgradeSensitiveState,
+			},
+		},
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
 			Delete: schema.DefaultTimeout(5 * time.Minute),
 		},
 
 		Schema: map[string]*schema.Schema{
+			"organization": organizationSchema(),
+			"vcs_type":     vcsTypeSchema(),
 			"project": {
-				Description: "The name of the CircleCI project to create the variable in",
+				Description: "The name of the CircleCI project to create the variable in, or its slug, e.g. `gh/org/repo` or `circleci/<org-id>/<project-id>`",
 				Type:        schema.TypeString,
 				Required:    true,
 				ForceNew:    true,
@@ -44,13 +61,24 @@ func resourceCircleCIEnvironmentVariable() *schema.Resource {
 				Type:        schema.TypeString,
 				Required:    true,
 				Sensitive:   true,
-				ForceNew:    true,
-				StateFunc: func(value interface{}) string {
-					/* To avoid storing the value of the environment variable in the state
-					but still be able to know when the value change, we store a hash of the value.
-					*/
-					return hashString(value.(string))
-				},
+				/* To avoid storing the value of the environment variable in the state
+				but still be able to know when the value change, we store a hash of the value,
+				unless it is not a secret.
+				*/
+				DiffSuppressFunc: suppressSensitiveValueDiff,
+			},
+			"sensitive":   sensitiveSchema(),
+			"plain_value": plainValueSchema(),
+			"masked_value": {
+				Description: "The masked value of the environment variable, `xxxx` followed by its last four characters. A value changed outside of Terraform is detected and written again.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"adopt_existing": {
+				Description: "Whether to take ownership of the variable if it already exists in the project, overwriting its value. Otherwise, creating a variable which already exists fails. Only used when the variable is created.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
 			},
 		},
 	}
@@ -63,60 +91,135 @@ func hashString(str string) string {
 	return base64.StdEncoding.EncodeToString(hash[:])
 }
 
+func resourceCircleCIEnvironmentVariableCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	if valueChanged(d) {
+		if err := d.SetNewComputed("masked_value"); err != nil {
+			return err
+		}
+	}
+
+	return customizePlainValueDiff(d)
+}
+
 func resourceCircleCIEnvironmentVariableCreate(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_environment_variable", schema.TimeoutCreate)
+	defer cancel()
 
 	project := d.Get("project").(string)
 	name := d.Get("name").(string)
 	value := d.Get("value").(string)
 
-	has, err := c.HasProjectEnvironmentVariable(project, name)
+	has, err := c.HasProjectEnvironmentVariable(ctx, project, name)
 	if err != nil {
-		return err
+		return describeAPIError(err, fmt.Sprintf("project %q", project))
 	}
 
-	if has {
-		return fmt.Errorf("environment variable already exists: %s", name)
+	if has && !d.Get("adopt_existing").(bool) {
+		id, _ := c.ComposeElementId([]string{project, name})
+		return fmt.Errorf("environment variable already exists: %s, either import it with `terraform import circleci_environment_variable.<name> %s` or set adopt_existing to overwrite it", name, id)
 	}
 
-	if err := c.CreateProjectEnvironmentVariable(project, name, value); err != nil {
-		return fmt.Errorf("failed to create environment variable: %w", err)
+	// An adopted variable is overwritten in place
+	if err := c.CreateProjectEnvironmentVariable(ctx, project, name, value); err != nil {
+		return fmt.Errorf("failed to create environment variable: %w", describeAPIError(err, fmt.Sprintf("project %q", project)))
 	}
 
 	id, _ := c.ComposeElementId([]string{project, name})
 
 	d.SetId(id)
+	_ = d.Set("masked_value", client.MaskProjectEnvironmentVariable(value))
+	setVariableValue(d, value)
 
 	return resourceCircleCIEnvironmentVariableRead(d, m)
 }
 
 func resourceCircleCIEnvironmentVariableRead(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_environment_variable", schema.TimeoutRead)
+	defer cancel()
+
+	setOrganization(d, c)
 
 	project := d.Get("project").(string)
 	name := d.Get("name").(string)
 
-	has, err := c.HasProjectEnvironmentVariable(project, name)
+	variable, err := c.GetProjectEnvironmentVariable(ctx, project, name)
 	if err != nil {
-		return fmt.Errorf("failed to get project environment variable: %w", err)
+		if errors.Is(err, client.ErrNotFound) {
+			d.SetId("")
+			return nil
+		}
+
+		return fmt.Errorf("failed to get project environment variable: %w", describeAPIError(err, fmt.Sprintf("project %q", project)))
 	}
 
-	if !has {
-		d.SetId("")
-		return nil
+	// The mask recorded when the value was written no longer matches, so the value was changed outside of
+	// Terraform. Forgetting the hash of the value makes the next apply write it again.
+	if masked := d.Get("masked_value").(string); masked != "" && masked != variable.Value {
+		_ = d.Set("value", "")
 	}
 
+	_ = d.Set("masked_value", variable.Value)
+
 	return nil
 }
 
+func resourceCircleCIEnvironmentVariableUpdate(d *schema.ResourceData, m interface{}) (err error) {
+	defer keepStateOnError(d, &err)
+
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_environment_variable", schema.TimeoutUpdate)
+	defer cancel()
+
+	project := d.Get("project").(string)
+	name := d.Get("name").(string)
+	value := d.Get("value").(string)
+
+	// Unless the value changed, the value of the state is its hash, which must not be written. Changes of
+	// adopt_existing only matter on creation.
+	if d.HasChange("value") {
+		// The endpoint overwrites an existing variable in place, so that builds never run without it
+		if err := c.CreateProjectEnvironmentVariable(ctx, project, name, value); err != nil {
+			return fmt.Errorf("failed to update environment variable: %w", describeAPIError(err, fmt.Sprintf("project %q", project)))
+		}
+
+		_ = d.Set("masked_value", client.MaskProjectEnvironmentVariable(value))
+	}
+
+	if d.HasChange("value") || d.HasChange("sensitive") {
+		setVariableValue(d, value)
+	}
+
+	return resourceCircleCIEnvironmentVariableRead(d, m)
+}
+
 func resourceCircleCIEnvironmentVariableDelete(d *schema.ResourceData, m interface{}) error {
-	c := m.(*client.Client)
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_environment_variable", schema.TimeoutDelete)
+	defer cancel()
 
 	project := d.Get("project").(string)
 	name := d.Get("name").(string)
 
-	if err := c.DeleteProjectEnvironmentVariable(project, name); err != nil {
-		return fmt.Errorf("failed to delete project environment variable: %w", err)
+	if err := c.DeleteProjectEnvironmentVariable(ctx, project, name); err != nil {
+		return fmt.Errorf("failed to delete project environment variable: %w", describeAPIError(err, fmt.Sprintf("project %q", project)))
 	}
 
 	d.SetId("")
@@ -125,7 +228,17 @@ func resourceCircleCIEnvironmentVariableDelete(d *schema.ResourceData, m interfa
 }
 
 func resourceCircleCIEnvironmentVariableImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-	c := m.(*client.Client)
+	importOrganization(d)
+
+	c, err := resourceClient(d, m)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := operationContext(c, d, "circleci_environment_variable", operationImport)
+	defer cancel()
+
+	setOrganization(d, c)
 
 	parts, err := c.DecomposeElementId(d.Id(), []string{"project", "name"})
 	if err != nil {
@@ -135,12 +248,51 @@ func resourceCircleCIEnvironmentVariableImport(d *schema.ResourceData, m interfa
 	project := parts["project"]
 	name := parts["name"]
 
-	if has, err := c.HasProjectEnvironmentVariable(project, name); !has || err != nil {
-		return nil, fmt.Errorf("environment variable does not exist: %w", err)
+	has, err := c.HasProjectEnvironmentVariable(ctx, project, name)
+	if err != nil {
+		return nil, fmt.Errorf("could not import environment variable: %w", describeAPIError(err, fmt.Sprintf("project %q", project)))
+	}
+
+	if !has {
+		return nil, fmt.Errorf("environment variable %s does not exist in project %q", name, project)
 	}
 
 	_ = d.Set("project", project)
 	_ = d.Set("name", name)
+	_ = d.Set("adopt_existing", false)
+	_ = d.Set("sensitive", true)
 
 	return []*schema.ResourceData{d}, nil
 }
+
+func resourceCircleCIEnvironmentVariableV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"organization": organizationSchema(),
+			"vcs_type":     vcsTypeSchema(),
+			"project": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"value": {
+				Type:      schema.TypeString,
+				Required:  true,
+				Sensitive: true,
+			},
+			"masked_value": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"adopt_existing": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+		},
+	}
+}
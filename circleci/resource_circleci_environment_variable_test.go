@@ -1,10 +1,16 @@
 package circleci
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path"
 	"regexp"
+	"sync"
 	"testing"
 
 	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
@@ -34,6 +40,7 @@ func TestAccCircleCIEnvironmentVariableCreateThenUpdate(t *testing.T) {
 					resource.TestCheckResourceAttr(resourceName, "project", project),
 					resource.TestCheckResourceAttr(resourceName, "name", envName),
 					resource.TestCheckResourceAttr(resourceName, "value", hashString("value-for-the-test")),
+					resource.TestCheckResourceAttr(resourceName, "masked_value", client.MaskProjectEnvironmentVariable("value-for-the-test")),
 				),
 			},
 			{
@@ -43,6 +50,7 @@ func TestAccCircleCIEnvironmentVariableCreateThenUpdate(t *testing.T) {
 					resource.TestCheckResourceAttr(resourceName, "project", project),
 					resource.TestCheckResourceAttr(resourceName, "name", envName),
 					resource.TestCheckResourceAttr(resourceName, "value", hashString("value-for-the-test-again")),
+					resource.TestCheckResourceAttr(resourceName, "masked_value", client.MaskProjectEnvironmentVariable("value-for-the-test-again")),
 				),
 			},
 		},
@@ -80,6 +88,73 @@ func TestAccCircleCIEnvironmentVariableCreateAlreadyExists(t *testing.T) {
 	})
 }
 
+func TestAccCircleCIEnvironmentVariableAdoptExisting(t *testing.T) {
+	project := os.Getenv("CIRCLECI_PROJECT")
+	envName := "TEST_" + acctest.RandString(8)
+
+	resourceName := "circleci_environment_variable." + envName
+
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		CheckDestroy: testAccCircleCIEnvironmentVariableCheckDestroy,
+		Steps: []resource.TestStep{
+			{
+				PreConfig: func() {
+					c := testAccProvider.Meta().(*client.Client)
+					if err := c.CreateProjectEnvironmentVariable(context.Background(), project, envName, "created-by-hand"); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config:      testAccCircleCIEnvironmentVariableConfig(project, envName, "value-for-the-test"),
+				ExpectError: regexp.MustCompile(regexp.QuoteMeta(fmt.Sprintf("terraform import circleci_environment_variable.<name> %s/%s", project, envName))),
+			},
+			{
+				Config: testAccCircleCIEnvironmentVariableConfigAdopt(project, envName, "value-for-the-test"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "id", fmt.Sprintf("%s/%s", project, envName)),
+					resource.TestCheckResourceAttr(resourceName, "adopt_existing", "true"),
+					resource.TestCheckResourceAttr(resourceName, "value", hashString("value-for-the-test")),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCircleCIEnvironmentVariableNotSensitive(t *testing.T) {
+	project := os.Getenv("CIRCLECI_PROJECT")
+	envName := "TEST_" + acctest.RandString(8)
+	resourceName := "circleci_environment_variable." + envName
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCircleCIEnvironmentVariableCheckDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCircleCIEnvironmentVariableConfigNotSensitive(project, envName, "eu-west-1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "sensitive", "false"),
+					resource.TestCheckResourceAttr(resourceName, "value", "eu-west-1"),
+					resource.TestCheckResourceAttr(resourceName, "plain_value", "eu-west-1"),
+				),
+			},
+			{
+				Config: testAccCircleCIEnvironmentVariableConfig(project, envName, "eu-west-1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "sensitive", "true"),
+					resource.TestCheckResourceAttr(resourceName, "value", hashString("eu-west-1")),
+					resource.TestCheckResourceAttr(resourceName, "plain_value", ""),
+				),
+			},
+		},
+	})
+}
+
 func TestParseEnvironmentVariableId(t *testing.T) {
 	orgs := []string{
 		acctest.RandString(8),
@@ -130,7 +205,7 @@ func testAccCircleCIEnvironmentVariableCheckDestroy(s *terraform.State) error {
 			continue
 		}
 
-		has, err := c.HasProjectEnvironmentVariable(rs.Primary.Attributes["project"], rs.Primary.Attributes["name"])
+		has, err := c.HasProjectEnvironmentVariable(context.Background(), rs.Primary.Attributes["project"], rs.Primary.Attributes["name"])
 		if err != nil {
 			return err
 		}
@@ -166,3 +241,173 @@ resource "circleci_environment_variable" "%[2]s_2" {
   value   = "%[3]s"
 }`, project, name, value)
 }
+
+func testAccCircleCIEnvironmentVariableConfigAdopt(project, name, value string) string {
+	return fmt.Sprintf(`
+resource "circleci_environment_variable" "%[2]s" {
+  project = "%[1]s"
+  name    = "%[2]s"
+  value   = "%[3]s"
+
+  adopt_existing = true
+}`, project, name, value)
+}
+
+func testAccCircleCIEnvironmentVariableConfigNotSensitive(project, name, value string) string {
+	return fmt.Sprintf(`
+resource "circleci_environment_variable" "%[2]s" {
+  project = "%[1]s"
+  name    = "%[2]s"
+  value   = "%[3]s"
+
+  sensitive = false
+}`, project, name, value)
+}
+
+// projectVariableServer serves a project environment variable, recording the values written to it
+type projectVariableServer struct {
+	mutex   sync.Mutex
+	masked  string
+	written []string
+	// failWrites rejects the values written to the variable
+	failWrites bool
+}
+
+func (s *projectVariableServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if r.Method == "POST" && s.failWrites {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"message": "Invalid value."}`))
+		return
+	}
+
+	if r.Method == "POST" {
+		var variable client.ProjectEnvironmentVariable
+		_ = json.NewDecoder(r.Body).Decode(&variable)
+		s.written = append(s.written, variable.Value)
+		s.masked = client.MaskProjectEnvironmentVariable(variable.Value)
+	}
+
+	_ = json.NewEncoder(w).Encode(client.ProjectEnvironmentVariable{Name: path.Base(r.URL.Path), Value: s.masked})
+}
+
+func testProjectVariableState(value string) *terraform.InstanceState {
+	return &terraform.InstanceState{
+		ID: "my-project/VAR",
+		Attributes: map[string]string{
+			"id":             "my-project/VAR",
+			"organization":   "org",
+			"vcs_type":       "gh",
+			"project":        "my-project",
+			"name":           "VAR",
+			"value":          hashString(value),
+			"sensitive":      "true",
+			"plain_value":    "",
+			"masked_value":   client.MaskProjectEnvironmentVariable(value),
+			"adopt_existing": "false",
+		},
+	}
+}
+
+func TestResourceCircleCIEnvironmentVariableUpdateAdoptExisting(t *testing.T) {
+	variables := &projectVariableServer{masked: client.MaskProjectEnvironmentVariable("secret")}
+	server := httptest.NewServer(variables)
+	defer server.Close()
+
+	c, err := client.New(client.Config{URL: server.URL + "/api/v2/", Organization: "org"})
+	assert.NoError(t, err)
+
+	r := resourceCircleCIEnvironmentVariable()
+	state := testProjectVariableState("secret")
+
+	diff, err := r.Diff(state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"project":        "my-project",
+		"name":           "VAR",
+		"value":          "secret",
+		"adopt_existing": true,
+	}), c)
+	assert.NoError(t, err)
+
+	updated, err := r.Apply(state, diff, c)
+	assert.NoError(t, err)
+
+	// Only adopt_existing changed, so the value is neither written nor hashed again
+	assert.Empty(t, variables.written)
+	assert.Equal(t, hashString("secret"), updated.Attributes["value"])
+	assert.Equal(t, "true", updated.Attributes["adopt_existing"])
+}
+
+func TestResourceCircleCIEnvironmentVariableDiff(t *testing.T) {
+	r := resourceCircleCIEnvironmentVariable()
+	config := map[string]interface{}{
+		"project": "my-project",
+		"name":    "VAR",
+		"value":   "secret",
+	}
+
+	// The value of the state is the hash of the configured one
+	diff, err := r.Diff(testProjectVariableState("secret"), terraform.NewResourceConfigRaw(config), nil)
+	assert.NoError(t, err)
+	assert.True(t, diff.Empty())
+
+	diff, err = r.Diff(testProjectVariableState("before"), terraform.NewResourceConfigRaw(config), nil)
+	assert.NoError(t, err)
+	assert.True(t, diff.Attributes["masked_value"].NewComputed)
+
+	plain := testProjectVariableState("secret")
+	plain.Attributes["value"] = "secret"
+	plain.Attributes["plain_value"] = "secret"
+	plain.Attributes["sensitive"] = "false"
+	config["sensitive"] = false
+
+	diff, err = r.Diff(plain, terraform.NewResourceConfigRaw(config), nil)
+	assert.NoError(t, err)
+	assert.True(t, diff.Empty())
+}
+
+func TestResourceCircleCIEnvironmentVariableReadChangedValue(t *testing.T) {
+	variables := &projectVariableServer{masked: client.MaskProjectEnvironmentVariable("secret")}
+	server := httptest.NewServer(variables)
+	defer server.Close()
+
+	c, err := client.New(client.Config{URL: server.URL + "/api/v2/", Organization: "org"})
+	assert.NoError(t, err)
+
+	d := resourceCircleCIEnvironmentVariable().Data(testProjectVariableState("secret"))
+	assert.NoError(t, resourceCircleCIEnvironmentVariableRead(d, c))
+	assert.Equal(t, hashString("secret"), d.Get("value"))
+
+	// The variable was changed outside of Terraform, so that its value no longer matches the state
+	variables.masked = client.MaskProjectEnvironmentVariable("changed")
+
+	assert.NoError(t, resourceCircleCIEnvironmentVariableRead(d, c))
+	assert.Equal(t, "", d.Get("value"))
+	assert.Equal(t, "xxxxnged", d.Get("masked_value"))
+}
+
+func TestResourceCircleCIEnvironmentVariableUpdateFailure(t *testing.T) {
+	variables := &projectVariableServer{masked: client.MaskProjectEnvironmentVariable("before"), failWrites: true}
+	server := httptest.NewServer(variables)
+	defer server.Close()
+
+	c, err := client.New(client.Config{URL: server.URL + "/api/v2/", Organization: "org"})
+	assert.NoError(t, err)
+
+	r := resourceCircleCIEnvironmentVariable()
+	state := testProjectVariableState("before")
+
+	diff, err := r.Diff(state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"project": "my-project",
+		"name":    "VAR",
+		"value":   "after",
+	}), c)
+	assert.NoError(t, err)
+
+	// The state of a failed update is the previous one, rather than the planned one holding the new value
+	updated, err := r.Apply(state, diff, c)
+	assert.Error(t, err)
+	assert.Equal(t, hashString("before"), updated.Attributes["value"])
+	assert.Equal(t, client.MaskProjectEnvironmentVariable("before"), updated.Attributes["masked_value"])
+}
@@ -0,0 +1,63 @@
+package circleci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func resourceCircleCIProjectEnvironmentVariables() *schema.Resource {
+	set := variableSet{
+		resourceType: "circleci_project_environment_variables",
+		owner:        "project",
+		newStore: func(c *client.Client, project string) variableStore {
+			return projectVariableStore{c: c, project: project}
+		},
+	}
+
+	return set.resource(&schema.Schema{
+		Description: "The name of the CircleCI project owning the variables, or its slug, e.g. `gh/org/repo` or `circleci/<org-id>/<project-id>`",
+		Type:        schema.TypeString,
+		Required:    true,
+		ForceNew:    true,
+	})
+}
+
+// projectVariableStore accesses the environment variables of a project, whose values are listed masked
+type projectVariableStore struct {
+	c       *client.Client
+	project string
+}
+
+func (s projectVariableStore) list(ctx context.Context) (map[string]string, error) {
+	variables, err := s.c.ListProjectEnvironmentVariables(ctx, s.project)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(variables))
+	for _, variable := range variables {
+		values[variable.Name] = variable.Value
+	}
+
+	return values, nil
+}
+
+func (s projectVariableStore) store(ctx context.Context, name, value string) error {
+	return s.c.CreateProjectEnvironmentVariable(ctx, s.project, name, value)
+}
+
+func (s projectVariableStore) remove(ctx context.Context, name string) error {
+	if err := s.c.DeleteProjectEnvironmentVariable(ctx, s.project, name); err != nil && !errors.Is(err, client.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (s projectVariableStore) target() string {
+	return fmt.Sprintf("project %q", s.project)
+}
@@ -0,0 +1,174 @@
+package circleci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccCircleCIProjectEnvironmentVariablesCreateThenUpdate(t *testing.T) {
+	project := os.Getenv("CIRCLECI_PROJECT")
+	first := "TEST_" + acctest.RandString(8)
+	second := "TEST_" + acctest.RandString(8)
+	resourceName := "circleci_project_environment_variables.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCircleCIProjectEnvironmentVariablesCheckDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCircleCIProjectEnvironmentVariablesConfig(project, map[string]string{
+					first:  "first-value",
+					second: "second-value",
+				}),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "id", project),
+					resource.TestCheckResourceAttr(resourceName, "project", project),
+					resource.TestCheckResourceAttr(resourceName, "variables."+first, hashString("first-value")),
+					resource.TestCheckResourceAttr(resourceName, "variables."+second, hashString("second-value")),
+				),
+			},
+			{
+				Config: testAccCircleCIProjectEnvironmentVariablesConfig(project, map[string]string{
+					first: "first-value-again",
+				}),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "variables.%", "1"),
+					resource.TestCheckResourceAttr(resourceName, "variables."+first, hashString("first-value-again")),
+					testAccCircleCIProjectEnvironmentVariableMissing(project, second),
+				),
+			},
+		},
+	})
+}
+
+func testAccCircleCIProjectEnvironmentVariableMissing(project, name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		c := testAccProvider.Meta().(*client.Client)
+
+		has, err := c.HasProjectEnvironmentVariable(context.Background(), project, name)
+		if err != nil {
+			return err
+		}
+
+		if has {
+			return fmt.Errorf("Environment variable %s should have been deleted", name)
+		}
+
+		return nil
+	}
+}
+
+func testAccCircleCIProjectEnvironmentVariablesCheckDestroy(s *terraform.State) error {
+	c := testAccProvider.Meta().(*client.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "circleci_project_environment_variables" {
+			continue
+		}
+
+		for key := range rs.Primary.Attributes {
+			name := strings.TrimPrefix(key, "variables.")
+			if name == key || name == "%" {
+				continue
+			}
+
+			has, err := c.HasProjectEnvironmentVariable(context.Background(), rs.Primary.Attributes["project"], name)
+			if err != nil {
+				return err
+			}
+
+			if has {
+				return errors.New("Environment variables should have been destroyed")
+			}
+		}
+	}
+
+	return nil
+}
+
+func testAccCircleCIProjectEnvironmentVariablesConfig(project string, variables map[string]string) string {
+	config := fmt.Sprintf(`
+resource "circleci_project_environment_variables" "test" {
+  project = "%s"
+
+  variables = {
+`, project)
+
+	for name, value := range variables {
+		config += fmt.Sprintf("    %s = %q\n", name, value)
+	}
+
+	return config + `  }
+
+  ignore_patterns = ["KEEP_*"]
+}`
+}
+
+func TestProjectVariableStore(t *testing.T) {
+	var mutex sync.Mutex
+	var requests []string
+	stored := map[string]string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch r.Method {
+		case "POST":
+			var variable client.ProjectEnvironmentVariable
+			_ = json.NewDecoder(r.Body).Decode(&variable)
+			stored[variable.Name] = variable.Value
+			_ = json.NewEncoder(w).Encode(variable)
+		case "DELETE":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Environment variable not found."}`))
+		default:
+			_, _ = w.Write([]byte(`{"items": [{"name": "FIRST", "value": "xxxxirst"}, {"name": "SECOND", "value": "xxxxcond"}], "next_page_token": null}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := client.New(client.Config{URL: server.URL + "/api/v2/", Organization: "org"})
+	assert.NoError(t, err)
+
+	store := projectVariableStore{c: c, project: "my-project"}
+	ctx := context.Background()
+
+	// Variables are listed with their masked values
+	variables, err := store.list(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"FIRST": "xxxxirst", "SECOND": "xxxxcond"}, variables)
+
+	assert.NoError(t, store.store(ctx, "FIRST", "first"))
+	assert.Equal(t, map[string]string{"FIRST": "first"}, stored)
+
+	// Variables which are already gone are not reported
+	assert.NoError(t, store.remove(ctx, "GONE"))
+
+	assert.Equal(t, []string{
+		"GET /api/v2/project/gh/org/my-project/envvar",
+		"POST /api/v2/project/gh/org/my-project/envvar",
+		"DELETE /api/v2/project/gh/org/my-project/envvar/GONE",
+	}, requests)
+	assert.Equal(t, `project "my-project"`, store.target())
+}
@@ -0,0 +1,134 @@
+package circleci
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/SectorLabs/terraform-provider-circleci/circleci/client/rest"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultTracesFile is where the "file" exporter writes spans unless OTEL_EXPORTER_FILE_PATH is set
+const defaultTracesFile = "terraform-provider-circleci-traces.json"
+
+// SetupTracing configures OpenTelemetry tracing from the standard OTEL_* environment variables.
+// OTEL_TRACES_EXPORTER selects the exporter:
+//   - "none" (default): tracing is disabled
+//   - "otlp": spans are sent over OTLP/HTTP, configured by the OTEL_EXPORTER_OTLP_* variables
+//   - "console": spans are written to stderr, i.e. to Terraform's log, since stdout is reserved for the plugin protocol
+//   - "file": spans are written to OTEL_EXPORTER_FILE_PATH, for offline use
+//
+// The returned function flushes pending spans and must be called before the process exits.
+func SetupTracing(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	noop := func(context.Context) error { return nil }
+
+	exporter := strings.ToLower(strings.TrimSpace(os.Getenv("OTEL_TRACES_EXPORTER")))
+	if exporter == "" || exporter == "none" {
+		return noop, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceNameKey.String("terraform-provider-circleci")),
+		resource.WithTelemetrySDK(),
+		// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return noop, fmt.Errorf("could not configure tracing resource: %w", err)
+	}
+
+	var processor sdktrace.SpanProcessor
+	var closer io.Closer
+	switch exporter {
+	case "otlp":
+		protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+		if protocol == "" {
+			protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+		}
+		if protocol != "" && protocol != "http/protobuf" {
+			return noop, fmt.Errorf("unsupported OTLP protocol %q, only http/protobuf is supported", protocol)
+		}
+
+		e, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return noop, fmt.Errorf("could not configure OTLP exporter: %w", err)
+		}
+		processor = sdktrace.NewBatchSpanProcessor(e)
+	case "console":
+		e, err := stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+		if err != nil {
+			return noop, fmt.Errorf("could not configure console exporter: %w", err)
+		}
+		processor = sdktrace.NewSimpleSpanProcessor(e)
+	case "file":
+		path := os.Getenv("OTEL_EXPORTER_FILE_PATH")
+		if path == "" {
+			path = defaultTracesFile
+		}
+
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return noop, fmt.Errorf("could not open traces file: %w", err)
+		}
+
+		e, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return noop, fmt.Errorf("could not configure file exporter: %w", err)
+		}
+		processor = sdktrace.NewSimpleSpanProcessor(e)
+		closer = f
+	default:
+		return noop, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q, expected one of none, otlp, console or file", exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			closer.Close()
+		}
+
+		return err
+	}, nil
+}
+
+// withParentTrace attaches the trace context found in the TRACEPARENT environment variable, if any,
+// so that the provider's spans join the trace of the Terraform run which started it
+func withParentTrace(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{
+		"traceparent": os.Getenv("TRACEPARENT"),
+		"tracestate":  os.Getenv("TRACESTATE"),
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// startOperationSpan starts the span of an operation of a resource or data source, e.g. circleci_context.create
+func startOperationSpan(ctx context.Context, resource, operation, id string) (context.Context, trace.Span) {
+	return otel.Tracer(rest.TracerName).Start(ctx, resource+"."+operation,
+		trace.WithAttributes(
+			attribute.String("terraform.resource.type", resource),
+			attribute.String("terraform.resource.id", id),
+		),
+	)
+}
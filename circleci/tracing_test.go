@@ -0,0 +1,55 @@
+package circleci
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client "github.com/SectorLabs/terraform-provider-circleci/circleci/client"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestOperationContextTracesOperation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": "4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c", "name": "production"}`))
+	}))
+	defer server.Close()
+
+	c, err := client.New(client.Config{URL: server.URL + "/api/v2/", Organization: "org"})
+	assert.NoError(t, err)
+
+	d := schema.TestResourceDataRaw(t, resourceCircleCIContext().Schema, map[string]interface{}{})
+	d.SetId("4f9d8f4c-8b5e-4c55-8f5b-0d1e8e6a2b7c")
+
+	ctx, cancel := operationContext(c, d, "circleci_context", schema.TimeoutRead)
+	_, err = c.GetContext(ctx, d.Id())
+	assert.NoError(t, err)
+	_, err = c.GetContext(ctx, d.Id())
+	assert.NoError(t, err)
+	cancel()
+
+	spans := map[string][]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+
+	// Every API call of the operation is a child of its span
+	assert.Len(t, spans["circleci_context.read"], 1)
+	operation := spans["circleci_context.read"][0]
+	assert.False(t, operation.Parent().IsValid())
+	assert.Len(t, spans["circleci.GetContext"], 2)
+	for _, span := range spans["circleci.GetContext"] {
+		assert.Equal(t, operation.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+}
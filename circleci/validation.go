@@ -3,7 +3,11 @@ package circleci
 import (
 	"errors"
 	"fmt"
+	"path"
 	"regexp"
+	"time"
+
+	"github.com/SectorLabs/terraform-provider-circleci/circleci/client"
 )
 
 var (
@@ -28,3 +32,63 @@ func validateEnvironmentVariableNameFunc(v interface{}, key string) (warns []str
 
 	return warns, errs
 }
+
+func validateEnvironmentVariableMapFunc(v interface{}, key string) (warns []string, errs []error) {
+	variables, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be map", key)}
+	}
+
+	for name := range variables {
+		_, nameErrs := validateEnvironmentVariableNameFunc(name, key)
+		for _, err := range nameErrs {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+		}
+	}
+
+	return warns, errs
+}
+
+func validateGlobPatternFunc(v interface{}, key string) (warns []string, errs []error) {
+	pattern, ok := v.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", key)}
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, []error{fmt.Errorf("%s must be a glob pattern such as \"AWS_*\": %v", key, err)}
+	}
+
+	return warns, errs
+}
+
+func validateDurationFunc(v interface{}, key string) (warns []string, errs []error) {
+	value, ok := v.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", key)}
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s must be a duration such as \"1s\" or \"500ms\": %v", key, err)}
+	}
+
+	if duration < 0 {
+		errs = append(errs, fmt.Errorf("%s cannot be negative", key))
+	}
+
+	return warns, errs
+}
+
+func validateServerVersionFunc(v interface{}, key string) (warns []string, errs []error) {
+	value, ok := v.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", key)}
+	}
+
+	if _, err := client.ParseServerVersion(value); err != nil {
+		return nil, []error{fmt.Errorf("%s must be a version such as \"3.4\" or \"4.1.2\": %v", key, err)}
+	}
+
+	return warns, errs
+}
@@ -45,3 +45,154 @@ func TestValidateEnvironmentVariableName(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateDuration(t *testing.T) {
+	cases := []struct {
+		Value string
+		Error bool
+	}{
+		{
+			Value: "1s",
+		},
+		{
+			Value: "500ms",
+		},
+		{
+			Value: "1m30s",
+		},
+		{
+			Value: "0s",
+		},
+		{
+			Value: "-1s",
+			Error: true,
+		},
+		{
+			Value: "10",
+			Error: true,
+		},
+		{
+			Value: "forever",
+			Error: true,
+		},
+	}
+
+	for _, tc := range cases {
+		var value interface{} = tc.Value
+		_, errors := validateDurationFunc(value, "backoff")
+
+		if tc.Error != (len(errors) != 0) {
+			if tc.Error {
+				t.Fatalf("expected error, got none (%s)", tc.Value)
+			} else {
+				t.Fatalf("unexpected error(s): %s (%s)", errors, tc.Value)
+			}
+		}
+	}
+}
+
+func TestValidateServerVersion(t *testing.T) {
+	cases := []struct {
+		Value string
+		Error bool
+	}{
+		{
+			Value: "",
+		},
+		{
+			Value: "3.4",
+		},
+		{
+			Value: "4.1.2",
+		},
+		{
+			Value: "v2.19",
+		},
+		{
+			Value: "4.x",
+			Error: true,
+		},
+		{
+			Value: "1.2.3.4",
+			Error: true,
+		},
+	}
+
+	for _, tc := range cases {
+		var value interface{} = tc.Value
+		_, errors := validateServerVersionFunc(value, "server_version")
+
+		if tc.Error != (len(errors) != 0) {
+			if tc.Error {
+				t.Fatalf("expected error, got none (%s)", tc.Value)
+			} else {
+				t.Fatalf("unexpected error(s): %s (%s)", errors, tc.Value)
+			}
+		}
+	}
+}
+
+func TestValidateGlobPattern(t *testing.T) {
+	cases := []struct {
+		Value string
+		Error bool
+	}{
+		{
+			Value: "AWS_*",
+		},
+		{
+			Value: "TOKEN_?",
+		},
+		{
+			Value: "[A-Z]*_KEY",
+		},
+		{
+			Value: "[A-Z",
+			Error: true,
+		},
+	}
+
+	for _, tc := range cases {
+		var value interface{} = tc.Value
+		_, errors := validateGlobPatternFunc(value, "ignore_patterns")
+
+		if tc.Error != (len(errors) != 0) {
+			if tc.Error {
+				t.Fatalf("expected error, got none (%s)", tc.Value)
+			} else {
+				t.Fatalf("unexpected error(s): %s (%s)", errors, tc.Value)
+			}
+		}
+	}
+}
+
+func TestValidateEnvironmentVariableMap(t *testing.T) {
+	cases := []struct {
+		Value map[string]interface{}
+		Error bool
+	}{
+		{
+			Value: map[string]interface{}{},
+		},
+		{
+			Value: map[string]interface{}{"VALID": "value", "_ALSO_VALID": ""},
+		},
+		{
+			Value: map[string]interface{}{"VALID": "value", "invalid-dashed": "value"},
+			Error: true,
+		},
+	}
+
+	for _, tc := range cases {
+		var value interface{} = tc.Value
+		_, errors := validateEnvironmentVariableMapFunc(value, "variables")
+
+		if tc.Error != (len(errors) != 0) {
+			if tc.Error {
+				t.Fatalf("expected error, got none (%v)", tc.Value)
+			} else {
+				t.Fatalf("unexpected error(s): %s (%v)", errors, tc.Value)
+			}
+		}
+	}
+}
@@ -1,13 +1,30 @@
 package main
 
 import (
+	"context"
+	"log"
+	"time"
+
 	"github.com/SectorLabs/terraform-provider-circleci/circleci"
 
 	"github.com/hashicorp/terraform-plugin-sdk/plugin"
 )
 
 func main() {
+	shutdownTracing, err := circleci.SetupTracing(context.Background())
+	if err != nil {
+		log.Printf("[WARN] tracing disabled: %v", err)
+	}
+
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: circleci.Provider,
 	})
+
+	// Terraform only waits briefly for the plugin to exit once it is done with it
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("[WARN] could not flush traces: %v", err)
+	}
 }